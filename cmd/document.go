@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sochoa/go-ls/internal/output"
+	"github.com/sochoa/go-ls/internal/stat"
+)
+
+// listDocument writes every match as YAML or TOML, with the immediate
+// children of directories nested beneath them.
+func listDocument(matches []string) error {
+	var nodes []output.Node
+	for _, match := range matches {
+		m, ok := argEntry(match)
+		if !ok {
+			continue
+		}
+		node := output.Node{Entry: m}
+		if m.GetType() == stat.DirectoryFileType {
+			node.Children = []output.Node{}
+			children, err := readChildren(match)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading directory %s: %v\n", match, err)
+			}
+			for _, child := range children {
+				node.Children = append(node.Children, output.Node{Entry: child.m})
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	return writeDocument(outputType, nodes)
+}
+
+// writeDocument writes nodes as TOML when typ is toml, as YAML otherwise.
+func writeDocument(typ string, nodes []output.Node) error {
+	if typ == outputTypeToml {
+		return output.TOML(os.Stdout, nodes)
+	}
+	return output.YAML(os.Stdout, nodes)
+}
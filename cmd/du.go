@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+
+	"github.com/sochoa/go-ls/internal/du"
+	"github.com/sochoa/go-ls/internal/mount"
+	"github.com/sochoa/go-ls/internal/output"
+	"github.com/sochoa/go-ls/internal/size"
+	"github.com/sochoa/go-ls/internal/stat"
+	"github.com/sochoa/go-ls/internal/walk"
+	"github.com/spf13/cobra"
+)
+
+// duOptions are the flags of go-ls du, kept apart from those of ls.
+type duOptions struct {
+	maxDepth      int
+	top           int
+	oneFileSystem bool
+	apparentSize  bool
+	humanReadable bool
+	si            bool
+	blockSize     string
+	output        string
+	jsonPretty    bool
+	columns       []string
+	// blockUnit formats sizes, from -h, --si and --block-size.
+	blockUnit size.Unit
+}
+
+var (
+	duOpts duOptions
+
+	duCmd = &cobra.Command{
+		Use:   "du [path...]",
+		Short: "Summarize the disk usage of each directory beneath the paths",
+		Long: "Summarize the disk usage of each directory beneath the paths, counting hard linked " +
+			"files once. Sizes are allocated space in 1K blocks unless --apparent-size, -h, --si " +
+			"or --block-size say otherwise. Structured output carries both sizes as disk_usage.",
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				args = []string{os.Getenv("PWD")}
+			}
+			return runDu(duOpts, args)
+		},
+	}
+)
+
+// runDu reports the disk usage of the directories beneath args.
+func runDu(o duOptions, args []string) error {
+	switch o.output {
+	case outputTypeText, outputTypeJson, outputTypeCsv, outputTypeTsv, outputTypeYaml, outputTypeToml:
+	default:
+		return fmt.Errorf("invalid output type %q, expected text, json, yaml, toml, csv or tsv", o.output)
+	}
+	var err error
+	if _, o.blockUnit, err = sizeUnits(o.humanReadable, o.si, o.blockSize); err != nil {
+		return err
+	}
+
+	w := walk.Walker{OneFileSystem: o.oneFileSystem}
+	if o.oneFileSystem {
+		if w.Mounts, err = mount.Load(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading the mount table: %v\n", err)
+		}
+	}
+	var counter du.Counter
+	for _, match := range expandArgs(args) {
+		err := counter.Walk(w, match, func(path string, err error) {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", path, err)
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error walking %s: %v\n", match, err)
+		}
+	}
+
+	var dirs []du.Dir
+	for _, d := range counter.Dirs() {
+		if o.maxDepth < 0 || d.Depth <= o.maxDepth {
+			dirs = append(dirs, d)
+		}
+	}
+	if o.top > 0 {
+		slices.SortStableFunc(dirs, func(a, b du.Dir) int {
+			return cmp.Compare(o.bytes(b), o.bytes(a))
+		})
+		dirs = dirs[:min(o.top, len(dirs))]
+	}
+	return o.write(dirs)
+}
+
+// bytes is the size of d shown and ranked by, allocated or apparent.
+func (o duOptions) bytes(d du.Dir) int64 {
+	if o.apparentSize {
+		return d.DiskUsage.ApparentBytes
+	}
+	return d.DiskUsage.AllocatedBytes
+}
+
+// write writes dirs the way du does, a size and a path per line, or as
+// entries in the structured output formats of ls.
+func (o duOptions) write(dirs []du.Dir) error {
+	entries := make([]stat.CommonStat, len(dirs))
+	for i, d := range dirs {
+		entries[i] = d
+	}
+	switch o.output {
+	case outputTypeJson:
+		for _, m := range entries {
+			s, err := m.Json(o.jsonPretty)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				continue
+			}
+			fmt.Println(s)
+		}
+		return nil
+	case outputTypeCsv, outputTypeTsv:
+		return writeRecords(entries, output.RecordOptions{
+			TSV:     o.output == outputTypeTsv,
+			Columns: o.columns,
+			Type:    reflect.TypeOf(du.Dir{}),
+		})
+	case outputTypeYaml, outputTypeToml:
+		nodes := make([]output.Node, len(entries))
+		for i, m := range entries {
+			nodes[i] = output.Node{Entry: m}
+		}
+		return writeDocument(o.output, nodes)
+	}
+	for _, d := range dirs {
+		fmt.Printf("%s\t%s\n", o.blockUnit.Format(o.bytes(d)), d.Path)
+	}
+	return nil
+}
+
+func init() {
+	duCmd.Flags().IntVarP(&duOpts.maxDepth, "max-depth", "d", -1,
+		"report directories at most N levels below the arguments (0 for the arguments alone)")
+	duCmd.Flags().IntVar(&duOpts.top, "top", 0,
+		"report only the N largest directories, largest first")
+	duCmd.Flags().BoolVarP(&duOpts.oneFileSystem, "one-file-system", "x", false,
+		"skip directories on other file systems and mount points")
+	duCmd.Flags().BoolVar(&duOpts.apparentSize, "apparent-size", false,
+		"show and rank by apparent sizes rather than allocated space")
+	duCmd.Flags().BoolVarP(&duOpts.humanReadable, "human-readable", "h", false,
+		"print sizes like 1K 234M 2G, in powers of 1024")
+	duCmd.Flags().BoolVar(&duOpts.si, "si", false,
+		"like -h, but use powers of 1000")
+	duCmd.Flags().StringVar(&duOpts.blockSize, "block-size", "",
+		"scale sizes by SIZE (e.g. K, M, G, KB, 1024, or '1 for thousands separators)")
+	duCmd.Flags().StringVar(&duOpts.output, "output", outputTypeText, "output type (text, json, yaml, toml, csv or tsv)")
+	duCmd.Flags().BoolVarP(&duOpts.jsonPretty, "json", "j", false, "indent json output")
+	duCmd.Flags().StringSliceVar(&duOpts.columns, "columns", nil,
+		"comma separated columns to write, in order, for csv and tsv output (default all)")
+	duCmd.Flags().Bool("help", false, "help for du")
+	rootCmd.AddCommand(duCmd)
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sochoa/go-ls/internal/digest"
+	"github.com/sochoa/go-ls/internal/dupes"
+	"github.com/sochoa/go-ls/internal/size"
+	"github.com/sochoa/go-ls/internal/stat"
+	"github.com/sochoa/go-ls/internal/walk"
+	"github.com/spf13/cobra"
+)
+
+// dupesOptions are the flags of go-ls dupes, kept apart from those of ls.
+type dupesOptions struct {
+	output        string
+	jsonPretty    bool
+	algorithm     string
+	minSize       string
+	useHashCache  bool
+	hashCacheFile string
+}
+
+var (
+	dupesOpts dupesOptions
+
+	dupesCmd = &cobra.Command{
+		Use:   "dupes [path...]",
+		Short: "Find files with identical content beneath the paths",
+		Long: "Find files with identical content beneath the paths. Files are compared by size, " +
+			"then by a hash of their first and last blocks, then by a hash of their whole content. " +
+			"Hard links to a file already seen are not counted as copies.",
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				args = []string{os.Getenv("PWD")}
+			}
+			return runDupes(dupesOpts, args)
+		},
+	}
+)
+
+// runDupes reports the sets of files with identical content beneath args.
+func runDupes(o dupesOptions, args []string) error {
+	opts := dupes.Options{Algorithm: o.algorithm}
+	if o.minSize != "" {
+		u, err := size.ParseBlockSize(o.minSize)
+		if err != nil || u.Human {
+			return fmt.Errorf("invalid minimum size %q", o.minSize)
+		}
+		opts.MinSize = u.BlockSize
+	}
+	switch o.output {
+	case outputTypeText, outputTypeJson, outputTypeCsv:
+	default:
+		return fmt.Errorf("invalid output type %q, expected text, json or csv", o.output)
+	}
+	cache, err := openHashCache(o.useHashCache, o.hashCacheFile)
+	if err != nil {
+		return err
+	}
+	defer saveHashCache(cache)
+	opts.Cache = cache
+	finder, err := dupes.New(opts)
+	if err != nil {
+		return err
+	}
+
+	var files []stat.Stat
+	for _, match := range expandArgs(args) {
+		files = append(files, collectFiles(walk.Walker{}, match)...)
+	}
+	sets, errs := finder.Find(files)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+	}
+	return o.write(sets)
+}
+
+// collectFiles returns the regular files beneath root.
+func collectFiles(w walk.Walker, root string) []stat.Stat {
+	var files []stat.Stat
+	err := w.Walk(root, func(path string, m stat.CommonStat, err error) error {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", path, err)
+			return nil
+		}
+		if m.GetType() == stat.RegularFileType {
+			files = append(files, m.GetStat())
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error walking %s: %v\n", root, err)
+	}
+	return files
+}
+
+// write writes the duplicate sets as text, one JSON object per set, or CSV
+// with one record per copy.
+func (o dupesOptions) write(sets []dupes.Set) error {
+	switch o.output {
+	case outputTypeJson:
+		for _, set := range sets {
+			b, err := json.Marshal(set)
+			if o.jsonPretty {
+				b, err = json.MarshalIndent(set, "", "  ")
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s\n", b)
+		}
+		return nil
+	case outputTypeCsv:
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"set", "size_bytes", "wasted_bytes", "algorithm", "hash", "path"})
+		for i, set := range sets {
+			for _, path := range set.Paths {
+				w.Write([]string{
+					strconv.Itoa(i + 1),
+					strconv.FormatInt(set.SizeBytes, 10),
+					strconv.FormatInt(set.WastedBytes, 10),
+					set.Algorithm,
+					set.Hash,
+					path,
+				})
+			}
+		}
+		w.Flush()
+		return w.Error()
+	}
+
+	var wasted int64
+	for i, set := range sets {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%d copies of %s (%s %s), %s wasted\n", len(set.Paths),
+			size.HumanReadable.Format(set.SizeBytes), set.Algorithm, set.Hash, size.HumanReadable.Format(set.WastedBytes))
+		fmt.Printf("  %s\n", strings.Join(set.Paths, "\n  "))
+		wasted += set.WastedBytes
+	}
+	if len(sets) > 0 {
+		fmt.Println()
+	}
+	fmt.Printf("%d sets of duplicates, %s wasted\n", len(sets), size.HumanReadable.Format(wasted))
+	return nil
+}
+
+func init() {
+	dupesCmd.Flags().StringVar(&dupesOpts.output, "output", outputTypeText, "output type (text, json or csv)")
+	dupesCmd.Flags().BoolVarP(&dupesOpts.jsonPretty, "json", "j", false, "indent json output")
+	dupesCmd.Flags().StringVar(&dupesOpts.algorithm, "hash", "sha256",
+		"compare whole files with "+strings.Join(digest.Algorithms(), ", "))
+	dupesCmd.Flags().StringVar(&dupesOpts.minSize, "min-size", "",
+		"ignore files smaller than SIZE, e.g. 1M")
+	dupesCmd.Flags().BoolVar(&dupesOpts.useHashCache, "hash-cache", false,
+		"reuse checksums of unchanged files from a cache under the user cache directory")
+	dupesCmd.Flags().StringVar(&dupesOpts.hashCacheFile, "hash-cache-file", "",
+		"like --hash-cache, but keep the cache in FILE")
+	rootCmd.AddCommand(dupesCmd)
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sochoa/go-ls/internal/output"
+	"github.com/sochoa/go-ls/internal/stat"
+)
+
+// listRecords writes every match, and the immediate children of those that
+// are directories, as CSV or TSV records under a single header.
+func listRecords(matches []string) error {
+	var entries []stat.CommonStat
+	for _, match := range matches {
+		m, ok := argEntry(match)
+		if !ok {
+			continue
+		}
+		if m.GetType() != stat.DirectoryFileType {
+			entries = append(entries, m)
+			continue
+		}
+		children, err := readChildren(match)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading directory %s: %v\n", match, err)
+			continue
+		}
+		for _, child := range children {
+			entries = append(entries, child.m)
+		}
+	}
+	return writeRecords(entries, recordOptions())
+}
+
+// recordOptions are the record format selected with --output and the
+// --columns selection.
+func recordOptions() output.RecordOptions {
+	return output.RecordOptions{
+		TSV:     outputType == outputTypeTsv,
+		Columns: columns,
+	}
+}
+
+// writeRecords writes entries as CSV or TSV records, as opts selects.
+func writeRecords(entries []stat.CommonStat, opts output.RecordOptions) error {
+	w, err := output.NewRecordWriter(os.Stdout, opts)
+	if err != nil {
+		return err
+	}
+	for _, m := range entries {
+		if err := w.Write(m); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+		}
+	}
+	return w.Flush()
+}
@@ -8,133 +8,406 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"syscall"
 
+	"github.com/sochoa/go-ls/internal/color"
+	"github.com/sochoa/go-ls/internal/digest"
+	"github.com/sochoa/go-ls/internal/elfinfo"
+	"github.com/sochoa/go-ls/internal/extent"
+	"github.com/sochoa/go-ls/internal/git"
+	"github.com/sochoa/go-ls/internal/hardlink"
+	"github.com/sochoa/go-ls/internal/mount"
+	"github.com/sochoa/go-ls/internal/order"
+	"github.com/sochoa/go-ls/internal/output"
+	"github.com/sochoa/go-ls/internal/sniff"
 	"github.com/sochoa/go-ls/internal/stat"
+	"github.com/sochoa/go-ls/internal/timefmt"
+	"github.com/sochoa/go-ls/internal/walk"
 	"github.com/spf13/cobra"
 )
 
 const (
 	outputTypeJson = "json"
 	outputTypeText = "text"
+	outputTypeDot  = "dot"
+	outputTypeCsv  = "csv"
+	outputTypeTsv  = "tsv"
+	outputTypeYaml = "yaml"
+	outputTypeToml = "toml"
+	outputTypeTree = "tree"
+
+	jsonTimeRFC3339 = "rfc3339"
+	jsonTimeEpoch   = "epoch"
 )
 
 var (
-	listLong   bool
-	jsonPretty bool
-	outputType string
-	rootCmd    = &cobra.Command{
+	listLong       bool
+	jsonPretty     bool
+	brokenLinks    bool
+	hardLinks      bool
+	realPath       bool
+	colorMode      string
+	columnGrid     bool
+	acrossRows     bool
+	onePerLine     bool
+	commaSeparated bool
+	humanReadable  bool
+	siUnits        bool
+	blockSize      string
+	showBlocks     bool
+	showInode      bool
+	timeWhich      string
+	timeStyleName  string
+	useUTC         bool
+	timeZoneName   string
+	jsonTime       string
+	format         string
+	formatFile     string
+	columns        []string
+	sortKey        string
+	sortNone       bool
+	sortSize       bool
+	sortTime       bool
+	sortExtension  bool
+	reverseSort    bool
+	treeDepth      int
+	charset        string
+	filterExpr     string
+	findFlags      = map[string]*string{}
+	findEmpty      bool
+	whereExpr      string
+	ignoreVCS      bool
+	ignorePatterns []string
+	hidePatterns   []string
+	gitStatus      bool
+	hashAlgorithms []string
+	hashMaxSize    string
+	useHashCache   bool
+	hashCacheFile  string
+	mimeTypes      bool
+	elfInfo        bool
+	extentMaps     bool
+	showMounts     bool
+	oneFileSystem  bool
+	fsInfo         bool
+	outputType     string
+	walker         walk.Walker
+	entryFilter    func(stat.CommonStat) (match, descend bool)
+	colors         *color.Scheme
+	rootCmd        = &cobra.Command{
 		Use: "ls",
+		// Arguments are paths; without this cobra rejects any argument that
+		// is not a subcommand name.
+		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
 				args = []string{os.Getenv("PWD")}
 			}
+			resolveLayout()
+			if err := resolveSizeUnits(); err != nil {
+				return err
+			}
+			if err := resolveTimeOptions(); err != nil {
+				return err
+			}
+			if err := resolveSortOptions(); err != nil {
+				return err
+			}
+			if err := resolveFilter(); err != nil {
+				return err
+			}
+			useColor, err := color.Enabled(colorMode, isTerminal(os.Stdout), os.Getenv("NO_COLOR"))
+			if err != nil {
+				return err
+			}
+			colors = nil
+			if useColor {
+				colors = color.Parse(os.Getenv("LS_COLORS"))
+			}
+			// The template's color helper paints with colors, and the
+			// walker only enriches entries with fields the template reads.
+			if err := loadTemplate(); err != nil {
+				return err
+			}
+			walker = newWalker()
+			if err := resolveIgnore(); err != nil {
+				return err
+			}
 
-			count := 0
-			argCount := len(args)
-			if argCount > 1 && outputType == outputTypeJson {
-				fmt.Printf("[")
+			if err := resolveDigests(); err != nil {
+				return err
 			}
+			defer saveHashCache(hashCache)
 
-			for idx := 0; idx < len(args); idx++ {
-				arg := args[idx]
-				arg = strings.TrimSpace(arg)
-				if arg == "" {
-					continue
+			matches := expandArgs(args)
+			switch {
+			case hardLinks:
+				return listHardLinks(collectHardLinks(matches))
+			case outputType == outputTypeDot:
+				var links []stat.StatLink
+				for _, match := range matches {
+					links = append(links, collectLinks(match)...)
 				}
-
-				if count > 0 && outputType == outputTypeJson {
-					fmt.Printf(",")
+				return output.Dot(os.Stdout, links)
+			case brokenLinks:
+				var broken []stat.CommonStat
+				for _, match := range matches {
+					broken = append(broken, collectBrokenLinks(match)...)
 				}
+				return listBroken(broken)
+			case outputType == outputTypeJson:
+				listEntries(matches)
+				return nil
+			case outputType == outputTypeCsv || outputType == outputTypeTsv:
+				return listRecords(matches)
+			case outputType == outputTypeYaml || outputType == outputTypeToml:
+				return listDocument(matches)
+			case outputType == outputTypeTree:
+				return listTree(matches)
+			}
+			return listText(matches)
+		},
+	}
+)
 
-				matches, err := filepath.Glob(arg)
-				if err != nil || len(matches) == 0 {
-					fmt.Fprintf(os.Stderr, "No matches found for %s\n", arg)
-					continue
-				} else if len(matches) > 1 {
-					left := args[:idx]
-					right := args[idx+1:]
-					args = append(append(left, matches...), right...)
-				}
+// expandArgs trims the arguments and expands any globs in them, reporting
+// the ones that match nothing.
+func expandArgs(args []string) []string {
+	var matches []string
+	for _, arg := range args {
+		arg = strings.TrimSpace(arg)
+		if arg == "" {
+			continue
+		}
+		found, err := filepath.Glob(arg)
+		if err != nil || len(found) == 0 {
+			fmt.Fprintf(os.Stderr, "No matches found for %s\n", arg)
+			continue
+		}
+		matches = append(matches, found...)
+	}
+	return matches
+}
 
-				for _, match := range matches {
-					var s syscall.Stat_t
-					err := syscall.Stat(match, &s)
-					if err != nil {
-						fmt.Fprintf(os.Stderr, "Error statting %s: %v\n", match, err)
-						continue
-					}
-
-					// Get stats for the current item
-					var m stat.CommonStat = stat.New(match, &s)
-					if m.GetType() == stat.SymbolicLinkFileType {
-						var l *stat.StatLink
-						l, err = stat.NewLink(m)
-						if err != nil {
-							fmt.Fprintf(os.Stderr, "Error reading symlink %s: %v\n", match, err)
-							continue
-						} else if l != nil {
-							m = *l
-						} else {
-							fmt.Fprintf(os.Stderr, "Error reading symlink %s: %v\n", match, err)
-							continue
-						}
-					}
-
-					if listLong {
-						jsonStr, err := m.Json(jsonPretty)
-						if err != nil {
-							fmt.Fprintf(os.Stderr, "error: %v\n", err)
-						} else {
-							fmt.Printf(jsonStr)
-						}
-					} else {
-						fmt.Println(m.GetAbsolutePath())
-					}
-
-					// If the current item is a directory, get its immediate children
-					if m.GetType() == stat.DirectoryFileType {
-						children, err := os.ReadDir(match)
-						if err != nil {
-							fmt.Fprintf(os.Stderr, "Error reading directory %s: %v\n", match, err)
-							continue
-						}
-
-						for _, child := range children {
-							childPath := filepath.Join(match, child.Name())
-							var childStat syscall.Stat_t
-							err := syscall.Stat(childPath, &childStat)
-							if err != nil {
-								fmt.Fprintf(os.Stderr, "Error statting %s: %v\n", childPath, err)
-								continue
-							}
-
-							childMeta := stat.New(childPath, &childStat)
-							if listLong {
-								jsonStr, err := childMeta.Json(jsonPretty)
-								if err != nil {
-									fmt.Fprintf(os.Stderr, "error: %v\n", err)
-								} else {
-									fmt.Printf("  %s\n", jsonStr) // Indent for clarity
-								}
-							} else {
-								fmt.Printf("%s\n", childMeta.AbsolutePath)
-							}
-						}
-					}
-
-					count++
-				}
+// listEntries writes every match, and the immediate children of those that
+// are directories, as JSON.
+func listEntries(matches []string) {
+	count := 0
+	argCount := len(matches)
+	if argCount > 1 && outputType == outputTypeJson {
+		fmt.Printf("[")
+	}
+
+	for _, match := range matches {
+		if count > 0 && outputType == outputTypeJson {
+			fmt.Printf(",")
+		}
+
+		// Get stats for the current item
+		m, ok := argEntry(match)
+		if !ok {
+			continue
+		}
+		printEntry(m, "")
+		count++
+
+		// If the current item is a directory, get its immediate children
+		if m.GetType() == stat.DirectoryFileType {
+			children, err := readChildren(match)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading directory %s: %v\n", match, err)
+				continue
 			}
 
-			if argCount > 1 && outputType == outputTypeJson {
-				fmt.Printf("]")
+			for _, child := range children {
+				printEntry(child.m, "  ") // Indent for clarity
 			}
-			fmt.Println()
+		}
+	}
+
+	if argCount > 1 && outputType == outputTypeJson {
+		fmt.Printf("]")
+	}
+	fmt.Println()
+}
+
+// printEntry writes a single entry as JSON. Children of a listed directory
+// are passed an indent.
+func printEntry(m stat.CommonStat, indent string) {
+	jsonStr, err := m.Json(jsonPretty)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return
+	}
+	fmt.Printf("%s%s\n", indent, jsonStr)
+}
+
+// collectBrokenLinks returns every dangling symbolic link found beneath root.
+func collectBrokenLinks(root string) []stat.CommonStat {
+	var broken []stat.CommonStat
+	err := walker.Walk(root, func(path string, m stat.CommonStat, err error) error {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", path, err)
 			return nil
-		},
+		}
+		if walk.IsDangling(m) {
+			broken = append(broken, m)
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error walking %s: %v\n", root, err)
 	}
-)
+	return broken
+}
+
+// collectHardLinks groups the entries beneath every match that share an
+// inode.
+func collectHardLinks(matches []string) []hardlink.Group {
+	var c hardlink.Collector
+	for _, match := range matches {
+		err := walker.Walk(match, func(path string, m stat.CommonStat, err error) error {
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", path, err)
+				return nil
+			}
+			c.Add(m)
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error walking %s: %v\n", match, err)
+		}
+	}
+	return c.Groups()
+}
+
+// collectLinks returns every symbolic link found beneath root.
+func collectLinks(root string) []stat.StatLink {
+	var links []stat.StatLink
+	err := walker.Walk(root, func(path string, m stat.CommonStat, err error) error {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", path, err)
+			return nil
+		}
+		if l, ok := m.(stat.StatLink); ok {
+			links = append(links, l)
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error walking %s: %v\n", root, err)
+	}
+	return links
+}
+
+// newWalker builds the walker for the enrichments selected on the command line.
+func newWalker() walk.Walker {
+	w := walk.Walker{Filter: entryFilter}
+	if realPath {
+		w.Enrichers = append(w.Enrichers, func(s *stat.Stat) {
+			var err error
+			s.RealPath, err = stat.RealPath(s.AbsolutePath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error resolving %s: %v\n", s.AbsolutePath, err)
+			}
+		})
+	}
+	if useUTC || timeZoneName != "" || jsonTime == jsonTimeEpoch {
+		epoch := jsonTime == jsonTimeEpoch
+		w.Enrichers = append(w.Enrichers, func(s *stat.Stat) {
+			for _, t := range s.Times() {
+				t.Time = t.In(timeZone)
+				t.Epoch = epoch
+			}
+		})
+	}
+	if gitStatus {
+		statuses := git.NewCache()
+		w.Enrichers = append(w.Enrichers, func(s *stat.Stat) {
+			var err error
+			s.GitStatus, err = statuses.Status(*s)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading git status of %s: %v\n", s.AbsolutePath, err)
+			}
+		})
+	}
+	if mimeTypes {
+		w.Enrichers = append(w.Enrichers, func(s *stat.Stat) {
+			if s.Type != stat.RegularFileType {
+				return
+			}
+			r, err := sniff.File(s.AbsolutePath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", s.AbsolutePath, err)
+				return
+			}
+			s.MimeType, s.Kind = r.MIME, r.Kind
+		})
+	}
+	if elfInfo && fieldWanted("elf", true) {
+		w.Enrichers = append(w.Enrichers, func(s *stat.Stat) {
+			// Only executables are parsed: files with an execute bit, as
+			// binaries and libraries are installed, or sniffed as one.
+			if s.Type != stat.RegularFileType || (s.Mode&0o111 == 0 && s.Kind != sniff.Executable) {
+				return
+			}
+			var err error
+			s.ELF, err = elfinfo.File(s.AbsolutePath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", s.AbsolutePath, err)
+			}
+		})
+	}
+	if extentMaps {
+		w.Enrichers = append(w.Enrichers, func(s *stat.Stat) {
+			if s.Type != stat.RegularFileType {
+				return
+			}
+			var err error
+			s.Extents, err = extent.File(s.AbsolutePath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error mapping %s: %v\n", s.AbsolutePath, err)
+			}
+		})
+	}
+	if showMounts || oneFileSystem {
+		var err error
+		w.Mounts, err = mount.Load()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading the mount table: %v\n", err)
+		}
+		w.OneFileSystem = oneFileSystem
+	}
+	if mounts := w.Mounts; showMounts && mounts != nil {
+		// Mount points are canonical paths, so the directories entries are
+		// listed under are resolved, once each.
+		realDirs := map[string]string{}
+		w.Enrichers = append(w.Enrichers, func(s *stat.Stat) {
+			dir := filepath.Dir(s.AbsolutePath)
+			realDir, ok := realDirs[dir]
+			if !ok {
+				var err error
+				if realDir, err = filepath.EvalSymlinks(dir); err != nil {
+					realDir = dir
+				}
+				realDirs[dir] = realDir
+			}
+			path := filepath.Join(realDir, filepath.Base(s.AbsolutePath))
+			s.Mount = mounts.Lookup(path)
+			s.IsMountPoint = mounts.IsMountPoint(path)
+		})
+	}
+	if humanReadable || siUnits || blockSize != "" {
+		w.Enrichers = append(w.Enrichers, func(s *stat.Stat) {
+			s.SizeHuman = sizeUnit.Format(s.SizeBytes)
+		})
+	}
+	return w
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
 
 func Execute() {
 	err := rootCmd.Execute()
@@ -146,6 +419,123 @@ func Execute() {
 func init() {
 	rootCmd.Flags().BoolVarP(&listLong, "long", "l", false,
 		"use a long listing format")
+	rootCmd.Flags().Bool("help", false, "help for ls")
 	rootCmd.Flags().BoolVarP(&jsonPretty, "json", "j", false, "use json output")
-	rootCmd.Flags().StringVar(&outputType, "output", "text", "output type (text or json)")
+	rootCmd.Flags().StringVar(&outputType, "output", outputTypeText, "output type (text, json, yaml, toml, csv, tsv, tree or dot)")
+	rootCmd.Flags().BoolVar(&brokenLinks, "broken-links", false,
+		"list only dangling symbolic links found anywhere beneath the arguments")
+	rootCmd.Flags().BoolVar(&hardLinks, "hardlinks", false,
+		"report files beneath the arguments that share an inode, with how many of their links were not found")
+	rootCmd.Flags().BoolVar(&realPath, "realpath", false,
+		"add the fully canonicalised path of every entry")
+	rootCmd.Flags().StringVar(&colorMode, "color", color.ModeAuto,
+		"colorize names using LS_COLORS (auto, always or never)")
+	rootCmd.Flags().Lookup("color").NoOptDefVal = color.ModeAlways
+	rootCmd.Flags().BoolVarP(&columnGrid, "vertical", "C", false,
+		"list entries by columns (the default on a terminal)")
+	rootCmd.Flags().BoolVar(&acrossRows, "across", false,
+		"list entries by lines instead of by columns (-x is --one-file-system)")
+	rootCmd.Flags().BoolVarP(&onePerLine, "one-per-line", "1", false,
+		"list one entry per line")
+	rootCmd.Flags().BoolVarP(&commaSeparated, "commas", "m", false,
+		"fill width with a comma separated list of entries")
+	rootCmd.Flags().BoolVarP(&humanReadable, "human-readable", "h", false,
+		"print sizes like 1K 234M 2G, in powers of 1024")
+	rootCmd.Flags().BoolVar(&siUnits, "si", false,
+		"like -h, but use powers of 1000")
+	rootCmd.Flags().StringVar(&blockSize, "block-size", "",
+		"scale sizes by SIZE (e.g. K, M, G, KB, 1024, or '1 for thousands separators)")
+	rootCmd.Flags().BoolVarP(&showBlocks, "blocks", "s", false,
+		"print the allocated size of each file, in blocks (--size is the find-style size test)")
+	rootCmd.Flags().BoolVarP(&showInode, "inode", "i", false,
+		"print the index number of each file")
+	rootCmd.Flags().StringVar(&timeWhich, "time", timefmt.Modified,
+		"timestamp to show: atime, ctime, birth or mtime")
+	rootCmd.Flags().StringVar(&timeStyleName, "time-style", "",
+		"time format: full-iso, long-iso, iso, locale or +FORMAT (default $TIME_STYLE or locale)")
+	rootCmd.Flags().BoolVar(&useUTC, "utc", false, "show times in UTC")
+	rootCmd.Flags().StringVar(&timeZoneName, "tz", "", "show times in the named time zone, e.g. Europe/Paris")
+	rootCmd.Flags().StringVar(&jsonTime, "json-time", jsonTimeRFC3339,
+		"timestamp encoding for structured output: rfc3339 or epoch")
+	rootCmd.Flags().StringVar(&format, "format", "",
+		"print each entry with a Go text/template, e.g. '{{.Permissions.Octal}} {{.BaseName}}', or pick a layout "+
+			"as in GNU ls: across, commas, horizontal, long, single-column, verbose or vertical")
+	rootCmd.Flags().StringVar(&formatFile, "format-file", "",
+		"read the --format template from a file")
+	rootCmd.Flags().StringSliceVar(&columns, "columns", nil,
+		"comma separated columns to write, in order, for csv and tsv output (default all)")
+	rootCmd.Flags().StringVar(&sortKey, "sort", order.Name,
+		"sort by none, name, size, time or extension")
+	rootCmd.Flags().BoolVarP(&sortNone, "unsorted", "U", false,
+		"do not sort; list entries in directory order")
+	rootCmd.Flags().BoolVarP(&sortSize, "sort-size", "S", false,
+		"sort by file size, largest first")
+	rootCmd.Flags().BoolVarP(&sortTime, "sort-time", "t", false,
+		"sort by the time chosen with --time, newest first")
+	rootCmd.Flags().BoolVarP(&sortExtension, "sort-extension", "X", false,
+		"sort alphabetically by entry extension")
+	rootCmd.Flags().BoolVarP(&reverseSort, "reverse", "r", false,
+		"reverse order while sorting")
+	rootCmd.Flags().IntVar(&treeDepth, "depth", 0,
+		"descend at most this many directory levels in tree output (0 for no limit)")
+	rootCmd.Flags().StringVar(&charset, "charset", "",
+		"tree connector characters: utf-8 or ascii (default from the locale)")
+	rootCmd.Flags().StringVar(&filterExpr, "filter", "",
+		"find-style expression, e.g. '(name \"*.go\" or name \"*.md\") and not empty'; "+
+			"name vendor prune skips a directory without reading it")
+	for _, f := range []struct{ predicate, usage string }{
+		{"type", "select entries of a type: f, d, l, b, c, p, s or a comma separated list"},
+		{"name", "select entries whose name matches a shell glob"},
+		{"iname", "like --name, but case insensitive"},
+		{"regex", "select entries whose absolute path matches a regular expression"},
+		{"size", "select by size as find -size does, e.g. +10M or -1k (-s is --blocks)"},
+		{"mtime", "select by modification age, e.g. -7d for under a week or +2h for over two hours"},
+		{"newer", "select entries modified more recently than FILE"},
+		{"user", "select entries owned by a user name or uid"},
+		{"group", "select entries owned by a group name or gid"},
+		{"perm", "select by octal permissions: exactly MODE, all bits of -MODE or any bit of /MODE"},
+		{"links", "select entries with N hard links, more than +N or fewer than -N"},
+		{"kind", "select files by content: " + strings.Join(sniff.Kinds, ", ") + " or a comma separated list"},
+	} {
+		findFlags[f.predicate] = rootCmd.Flags().String(f.predicate, "", f.usage)
+	}
+	rootCmd.Flags().BoolVar(&findEmpty, "empty", false,
+		"select empty files and directories")
+	rootCmd.Flags().StringVar(&whereExpr, "where", "",
+		"select entries with an expression over their JSON fields, "+
+			"e.g. 'size_bytes > 1<<20 && permissions.symbolic.other.write'")
+	rootCmd.Flags().BoolVar(&ignoreVCS, "ignore-vcs", false,
+		"hide entries ignored by .gitignore, .git/info/exclude, the global excludes file or .ignore files")
+	rootCmd.Flags().StringArrayVar(&ignorePatterns, "ignore", nil,
+		"do not list entries whose names match the shell PATTERN")
+	rootCmd.Flags().StringArrayVar(&hidePatterns, "hide", nil,
+		"do not list entries whose names match the shell PATTERN")
+	rootCmd.Flags().BoolVar(&gitStatus, "git", false,
+		"show the git status of each entry, as in git status --short, in the long format and as git_status")
+	rootCmd.Flags().BoolVar(&mimeTypes, "mime", false,
+		"detect the MIME type and kind of regular files from their content, shown in the long format "+
+			"and as mime_type and kind; implied by selecting on them with --kind, --filter or --where")
+	rootCmd.Flags().BoolVar(&elfInfo, "elf", false,
+		"describe ELF executables and libraries, in the long format and as elf: architecture, type, "+
+			"interpreter, needed libraries, whether they are stripped and the build information of Go binaries; "+
+			"files without an execute bit are only read when --mime finds them executable")
+	rootCmd.Flags().BoolVar(&extentMaps, "extents", false,
+		"map the data and hole ranges of regular files as extents, with FIEMAP details where available")
+	rootCmd.Flags().BoolVar(&showMounts, "mounts", false,
+		"add the mount each entry lives on, with its file system type, source and options, "+
+			"and whether the entry is a mount point itself")
+	rootCmd.Flags().BoolVarP(&oneFileSystem, "one-file-system", "x", false,
+		"do not descend into mount points or directories on other file systems when walking beneath "+
+			"the arguments")
+	rootCmd.Flags().BoolVar(&fsInfo, "fs-info", false,
+		"report the capacity, inode counts, type and mount flags of the file system of each argument, "+
+			"as a header line and as filesystem")
+	rootCmd.Flags().StringSliceVar(&hashAlgorithms, "hash", nil,
+		"checksum regular files with "+strings.Join(digest.Algorithms(), ", ")+" (comma separated for several)")
+	rootCmd.Flags().StringVar(&hashMaxSize, "hash-max-size", "",
+		"do not checksum files larger than SIZE, e.g. 100M")
+	rootCmd.Flags().BoolVar(&useHashCache, "hash-cache", false,
+		"reuse checksums of unchanged files from a cache under the user cache directory")
+	rootCmd.Flags().StringVar(&hashCacheFile, "hash-cache-file", "",
+		"like --hash-cache, but keep the cache in FILE")
 }
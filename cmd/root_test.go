@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"debug/elf"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+)
+
+// runLs runs go-ls with args and returns what it wrote to standard output.
+// Flags are reset to their defaults first, as in a fresh process.
+func runLs(t *testing.T, args ...string) string {
+	t.Helper()
+	rootCmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if s, ok := f.Value.(pflag.SliceValue); ok {
+			require.NoError(t, s.Replace(nil))
+		} else {
+			require.NoError(t, f.Value.Set(f.DefValue))
+		}
+		f.Changed = false
+	})
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	out := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(r)
+		out <- b
+	}()
+
+	rootCmd.SetArgs(args)
+	err = rootCmd.Execute()
+	require.NoError(t, w.Close())
+	b := <-out
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestTemplateColor(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), nil, 0o644))
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("LS_COLORS", "*.txt=01;31")
+
+	got := runLs(t, "--format", "{{color . .BaseName}}", "--color=always", filepath.Join(dir, "a.txt"))
+	require.Equal(t, "\x1b[01;31ma.txt\x1b[0m\n", got)
+}
+
+// elfHeader is the smallest ELF file: a header without sections or
+// program headers.
+func elfHeader(t *testing.T) []byte {
+	t.Helper()
+	h := elf.Header64{
+		Type:    uint16(elf.ET_EXEC),
+		Machine: uint16(elf.EM_X86_64),
+		Version: uint32(elf.EV_CURRENT),
+		Ehsize:  64,
+	}
+	copy(h.Ident[:], elf.ELFMAG)
+	h.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	h.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	h.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+	var buf bytes.Buffer
+	require.NoError(t, binary.Write(&buf, binary.LittleEndian, h))
+	return buf.Bytes()
+}
+
+func TestTreeLongEnrichments(t *testing.T) {
+	dir := t.TempDir()
+	content := elfHeader(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "prog"), content, 0o755))
+	sum := sha256.Sum256(content)
+
+	got := runLs(t, "--output", "tree", "-l", "--hash", "sha256", "--elf", "--color=never", dir)
+	require.Contains(t, got, hex.EncodeToString(sum[:]))
+	require.Contains(t, got, "x86_64,exec,static,stripped")
+}
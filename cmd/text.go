@@ -0,0 +1,837 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sochoa/go-ls/internal/digest"
+	"github.com/sochoa/go-ls/internal/filter"
+	"github.com/sochoa/go-ls/internal/fsinfo"
+	"github.com/sochoa/go-ls/internal/hardlink"
+	"github.com/sochoa/go-ls/internal/ignore"
+	"github.com/sochoa/go-ls/internal/layout"
+	"github.com/sochoa/go-ls/internal/order"
+	"github.com/sochoa/go-ls/internal/output"
+	"github.com/sochoa/go-ls/internal/query"
+	"github.com/sochoa/go-ls/internal/size"
+	"github.com/sochoa/go-ls/internal/stat"
+	"github.com/sochoa/go-ls/internal/timefmt"
+)
+
+var (
+	// sizeUnit formats file sizes and blockUnit allocated blocks, both
+	// chosen from -h, --si and --block-size.
+	sizeUnit  = size.Bytes
+	blockUnit = size.Kibibytes
+
+	// timeField, timeStyle and timeZone come from --time, --time-style,
+	// --utc and --tz. now is fixed once per run so every entry is judged
+	// recent or old against the same moment.
+	timeField = timefmt.Modified
+	timeStyle timefmt.Style
+	timeZone  = time.Local
+	now       time.Time
+
+	// sortOptions is resolved from --sort, -U, -S, -t, -X and -r.
+	sortOptions order.Options
+
+	// whereQuery is the compiled --where expression, nil without one.
+	whereQuery *query.Query
+	// entryTemplate is the parsed --format or --format-file template.
+	entryTemplate *output.Template
+
+	// digester computes --hash checksums, with hashCache behind it.
+	digester  *digest.Digester
+	hashCache *digest.Cache
+)
+
+// textEntry is an entry along with the name it is shown under.
+type textEntry struct {
+	m    stat.CommonStat
+	name string
+}
+
+// listText writes matches the way ls does: file arguments first, then each
+// directory's children under a heading when there is more than one argument.
+// Names are shown relative to the argument they came from.
+func listText(matches []string) error {
+	var (
+		args  []textEntry
+		files []textEntry
+		dirs  []string
+	)
+	for _, match := range matches {
+		m, ok := argEntry(match)
+		if !ok {
+			continue
+		}
+		args = append(args, textEntry{m: m, name: match})
+		if m.GetType() == stat.DirectoryFileType {
+			dirs = append(dirs, match)
+		} else {
+			files = append(files, textEntry{m: m, name: match})
+		}
+	}
+
+	if fsInfo && entryTemplate == nil {
+		writeFileSystems(args)
+	}
+	sortEntries(files)
+	if err := writeEntries(files, false); err != nil {
+		return err
+	}
+	for i, dir := range dirs {
+		if len(matches) > 1 && entryTemplate == nil {
+			if i > 0 || len(files) > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s:\n", dir)
+		}
+		children, err := readChildren(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading directory %s: %v\n", dir, err)
+			continue
+		}
+		if err := writeEntries(children, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listBroken writes dangling links found by --broken-links under their
+// absolute paths.
+func listBroken(broken []stat.CommonStat) error {
+	switch outputType {
+	case outputTypeJson:
+		for _, m := range broken {
+			printEntry(m, "")
+		}
+		return nil
+	case outputTypeCsv, outputTypeTsv:
+		return writeRecords(broken, recordOptions())
+	case outputTypeYaml, outputTypeToml:
+		nodes := make([]output.Node, 0, len(broken))
+		for _, m := range broken {
+			nodes = append(nodes, output.Node{Entry: m})
+		}
+		return writeDocument(outputType, nodes)
+	}
+	entries := make([]textEntry, 0, len(broken))
+	for _, m := range broken {
+		entries = append(entries, textEntry{m: m, name: m.GetAbsolutePath()})
+	}
+	return writeEntries(entries, false)
+}
+
+// listHardLinks writes the groups found by --hardlinks, one JSON object per
+// group or, as text, each inode followed by its paths and a closing total.
+func listHardLinks(groups []hardlink.Group) error {
+	if outputType == outputTypeJson {
+		for _, g := range groups {
+			b, err := json.Marshal(g)
+			if jsonPretty {
+				b, err = json.MarshalIndent(g, "", "  ")
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s\n", b)
+		}
+		return nil
+	}
+	if outputType != outputTypeText {
+		return fmt.Errorf("--hardlinks supports text and json output, not %s", outputType)
+	}
+	var paths, missing uint64
+	for i, g := range groups {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("inode %d on device %s: %d links, %d not found\n", g.Inode, g.Device, g.Links, g.Missing)
+		for _, path := range g.Paths {
+			fmt.Printf("  %s\n", path)
+		}
+		paths += uint64(len(g.Paths))
+		missing += g.Missing
+	}
+	if len(groups) > 0 {
+		fmt.Println()
+	}
+	fmt.Printf("%d inodes with several links, %d paths, %d links not found\n", len(groups), paths, missing)
+	return nil
+}
+
+// argEntry follows a command line argument. Directories are always
+// returned so their contents can be listed; other entries only when they
+// pass the filter.
+func argEntry(path string) (stat.CommonStat, bool) {
+	m, err := walker.Follow(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return nil, false
+	}
+	if fsInfo {
+		m = withFileSystem(m)
+	}
+	if m.GetType() == stat.DirectoryFileType {
+		return m, true
+	}
+	entries := []textEntry{{m: m}}
+	hashEntries(entries)
+	m = entries[0].m
+	match, _ := walker.Match(m)
+	return m, match
+}
+
+// fileSystems caches the --fs-info capacity of each device seen.
+var fileSystems = map[stat.DeviceID]*fsinfo.FileSystem{}
+
+// withFileSystem returns a copy of m carrying the capacity of the file
+// system it lives on, from one statfs call per device. Links are only left
+// unfollowed when dangling, so their directory is asked instead.
+func withFileSystem(m stat.CommonStat) stat.CommonStat {
+	s := m.GetStat()
+	fs, ok := fileSystems[s.Device]
+	if !ok {
+		path := s.AbsolutePath
+		if _, isLink := m.(stat.StatLink); isLink {
+			path = filepath.Dir(path)
+		}
+		var err error
+		if fs, err = fsinfo.Stat(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading the file system of %s: %v\n", path, err)
+		}
+		fileSystems[s.Device] = fs
+	}
+	if l, ok := m.(stat.StatLink); ok {
+		l.FileSystem = fs
+		return l
+	}
+	s.FileSystem = fs
+	return s
+}
+
+// writeFileSystems writes a header line for each file system the entries
+// live on, naming the arguments on it.
+func writeFileSystems(entries []textEntry) {
+	var devices []stat.DeviceID
+	names := map[stat.DeviceID][]string{}
+	for _, e := range entries {
+		s := e.m.GetStat()
+		if s.FileSystem == nil {
+			continue
+		}
+		if _, ok := names[s.Device]; !ok {
+			devices = append(devices, s.Device)
+		}
+		names[s.Device] = append(names[s.Device], e.name)
+	}
+	for _, d := range devices {
+		fs := fileSystems[d]
+		typ := fs.Type
+		if typ == "" {
+			typ = fs.Magic
+		}
+		fmt.Printf("filesystem %s (%s) of %s: %s total, %s used, %s available, %d inodes, %d free\n",
+			typ, strings.Join(fs.Flags, ","), strings.Join(names[d], ", "),
+			size.HumanReadable.Format(int64(fs.TotalBytes)), size.HumanReadable.Format(int64(fs.UsedBytes)),
+			size.HumanReadable.Format(int64(fs.AvailableBytes)), fs.Inodes, fs.InodesFree)
+	}
+}
+
+// hashEntries fills in the --hash checksums of the regular files among
+// entries, reading several at once.
+func hashEntries(entries []textEntry) {
+	if digester == nil {
+		return
+	}
+	var (
+		indexes []int
+		paths   []string
+	)
+	for i, e := range entries {
+		if s, ok := e.m.(stat.Stat); ok && s.Type == stat.RegularFileType {
+			indexes = append(indexes, i)
+			paths = append(paths, s.AbsolutePath)
+		}
+	}
+	sums, errs := digester.SumAll(paths)
+	for j, i := range indexes {
+		if errs[j] != nil {
+			fmt.Fprintf(os.Stderr, "Error hashing %s: %v\n", paths[j], errs[j])
+			continue
+		}
+		s := entries[i].m.(stat.Stat)
+		s.Hashes = sums[j]
+		entries[i].m = s
+	}
+}
+
+// readChildren returns the entries of dir that pass the filter, sorted.
+func readChildren(dir string) ([]textEntry, error) {
+	entries, err := readDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(entries, func(e textEntry) bool {
+		match, _ := walker.Match(e.m)
+		return !match
+	}), nil
+}
+
+// readDir returns the entries of dir that are not ignored, sorted.
+func readDir(dir string) ([]textEntry, error) {
+	children, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]textEntry, 0, len(children))
+	for _, child := range children {
+		path := filepath.Join(dir, child.Name())
+		if walker.Ignored(path, child.IsDir()) {
+			continue
+		}
+		m, err := walker.Entry(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			continue
+		}
+		entries = append(entries, textEntry{m: m, name: child.Name()})
+	}
+	hashEntries(entries)
+	sortEntries(entries)
+	return entries, nil
+}
+
+// sortEntries orders entries as selected with --sort and its shorthands.
+func sortEntries(entries []textEntry) {
+	order.Sort(entries, func(e textEntry) stat.Stat { return e.m.GetStat() }, sortOptions)
+}
+
+// writeEntries writes one group of entries in the long or short format.
+// Directory contents are preceded by their total allocated size when sizes
+// are shown, as with ls -l and ls -s.
+func writeEntries(entries []textEntry, isDir bool) error {
+	if entryTemplate != nil {
+		for _, e := range entries {
+			if err := entryTemplate.Execute(os.Stdout, e.m); err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+			}
+		}
+		return nil
+	}
+	if isDir && (listLong || showBlocks) {
+		var total int64
+		for _, e := range entries {
+			total += size.Allocated(e.m.GetStat().NumBlocks)
+		}
+		fmt.Printf("total %s\n", blockUnit.Format(total))
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	if listLong {
+		rows := make([][]string, 0, len(entries))
+		for _, e := range entries {
+			rows = append(rows, longRow(e))
+		}
+		return layout.WriteTable(os.Stdout, rows, longAligns())
+	}
+
+	names := make([]string, 0, len(entries))
+	if showInode || showBlocks {
+		// Right-align the inode numbers and block counts so the names start
+		// in one column.
+		rows := make([][]string, 0, len(entries))
+		for _, e := range entries {
+			s := e.m.GetStat()
+			var row []string
+			if showInode {
+				row = append(row, strconv.FormatUint(s.Inode, 10))
+			}
+			if showBlocks {
+				row = append(row, blockUnit.Format(size.Allocated(s.NumBlocks)))
+			}
+			rows = append(rows, row)
+		}
+		padded := alignRight(rows)
+		for i, e := range entries {
+			names = append(names, padded[i]+" "+displayName(e.m, e.name))
+		}
+	} else {
+		for _, e := range entries {
+			names = append(names, displayName(e.m, e.name))
+		}
+	}
+	return layout.Write(os.Stdout, names, shortFormat(), layout.TerminalWidth(os.Stdout))
+}
+
+// longRow returns the ls -l fields for e: mode, link count, owner, group,
+// size, the time chosen with --time and name.
+func longRow(e textEntry) []string {
+	return append(longColumns(e.m), linkName(e.m, e.name))
+}
+
+// longColumns returns the ls -l fields of m that come before its name.
+func longColumns(m stat.CommonStat) []string {
+	s := m.GetStat()
+	var row []string
+	if showInode {
+		row = append(row, strconv.FormatUint(s.Inode, 10))
+	}
+	if showBlocks {
+		row = append(row, blockUnit.Format(size.Allocated(s.NumBlocks)))
+	}
+	// Device files show their device number where others show a size.
+	sizeField := sizeUnit.Format(s.SizeBytes)
+	if s.Rdev != nil {
+		sizeField = s.Rdev.String()
+	}
+	row = append(row,
+		s.ModeString(),
+		strconv.FormatUint(s.HardLinkReferenceCount, 10),
+		s.UserName,
+		s.GroupName,
+		sizeField,
+		timeStyle.Format(entryTime(s), now),
+	)
+	if gitStatus {
+		// Outside a work tree the column is blank but keeps its width.
+		row = append(row, fmt.Sprintf("%-2s", s.GitStatus))
+	}
+	if mimeTypes {
+		row = append(row, placeholder(s.Kind), placeholder(s.MimeType))
+	}
+	if elfInfo {
+		elfField := "-"
+		if s.ELF != nil {
+			elfField = s.ELF.String()
+		}
+		row = append(row, elfField)
+	}
+	for _, algorithm := range hashAlgorithms {
+		row = append(row, placeholder(s.Hashes[algorithm]))
+	}
+	return row
+}
+
+// placeholder shows a missing optional value as "-".
+func placeholder(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// entryTime returns the timestamp of s selected with --time.
+func entryTime(s stat.Stat) time.Time {
+	switch timeField {
+	case timefmt.Accessed:
+		return s.LastAccessedTime.Time
+	case timefmt.Changed:
+		return s.CreateTime.Time
+	case timefmt.Birth:
+		return s.BirthTime.Time
+	}
+	return s.LastModifiedTime.Time
+}
+
+func longAligns() []layout.Align {
+	var aligns []layout.Align
+	if showInode {
+		aligns = append(aligns, layout.Right)
+	}
+	if showBlocks {
+		aligns = append(aligns, layout.Right)
+	}
+	aligns = append(aligns,
+		layout.Left,  // mode
+		layout.Right, // links
+		layout.Left,  // owner
+		layout.Left,  // group
+		layout.Right, // size
+		layout.Left,  // time
+	)
+	if gitStatus {
+		aligns = append(aligns, layout.Left)
+	}
+	if mimeTypes {
+		aligns = append(aligns, layout.Left, layout.Left)
+	}
+	if elfInfo {
+		aligns = append(aligns, layout.Left)
+	}
+	for range hashAlgorithms {
+		aligns = append(aligns, layout.Left)
+	}
+	return append(aligns, layout.Left) // name
+}
+
+// alignRight pads each cell of rows on the left to the width of the widest
+// cell in its column and joins the cells of a row with spaces.
+func alignRight(rows [][]string) []string {
+	var widest []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widest) {
+				widest = append(widest, 0)
+			}
+			widest[i] = max(widest[i], layout.Width(cell))
+		}
+	}
+	padded := make([]string, len(rows))
+	for i, row := range rows {
+		cells := make([]string, len(row))
+		for j, cell := range row {
+			cells[j] = fmt.Sprintf("%*s", widest[j], cell)
+		}
+		padded[i] = strings.Join(cells, " ")
+	}
+	return padded
+}
+
+// layoutFormats are the --format words of GNU ls, which pick a layout
+// instead of naming a template.
+var layoutFormats = map[string]*bool{
+	"across":        &acrossRows,
+	"commas":        &commaSeparated,
+	"horizontal":    &acrossRows,
+	"long":          &listLong,
+	"single-column": &onePerLine,
+	"verbose":       &listLong,
+	"vertical":      &columnGrid,
+}
+
+// resolveLayout turns a --format layout word into the flag it stands for.
+func resolveLayout() {
+	if set, ok := layoutFormats[format]; ok {
+		*set = true
+		format = ""
+	}
+}
+
+// shortFormat picks the layout from the -1, -m, --across and -C flags,
+// defaulting to columns on a terminal and one name per line otherwise.
+func shortFormat() layout.Format {
+	switch {
+	case onePerLine:
+		return layout.OnePerLine
+	case commaSeparated:
+		return layout.Commas
+	case acrossRows:
+		return layout.Across
+	case columnGrid || isTerminal(os.Stdout):
+		return layout.Columns
+	}
+	return layout.OnePerLine
+}
+
+// linkName is displayName followed by the target of a link that resolves,
+// as in long listings.
+func linkName(m stat.CommonStat, name string) string {
+	name = displayName(m, name)
+	if l, ok := m.(stat.StatLink); ok && !l.Dangling {
+		name = fmt.Sprintf("%s -> %s", name, l.Target())
+	}
+	return name
+}
+
+// displayName colours name for m and, for dangling links, appends the
+// missing target.
+func displayName(m stat.CommonStat, name string) string {
+	if colors != nil {
+		name = colors.Paint(colors.For(m, name), name)
+	}
+	if l, ok := m.(stat.StatLink); ok && l.Dangling {
+		target := l.Target()
+		if colors != nil {
+			target = colors.Paint(colors.Missing(), target)
+		}
+		name = fmt.Sprintf("%s -> %s", name, target)
+	}
+	return name
+}
+
+// resolveSizeUnits sets sizeUnit and blockUnit from -h, --si and
+// --block-size.
+func resolveSizeUnits() error {
+	var err error
+	sizeUnit, blockUnit, err = sizeUnits(humanReadable, siUnits, blockSize)
+	return err
+}
+
+// sizeUnits returns the units of file sizes and of allocated blocks for
+// -h, --si and --block-size; -h and --si take precedence.
+func sizeUnits(humanReadable, si bool, blockSize string) (sizeUnit, blockUnit size.Unit, err error) {
+	sizeUnit, blockUnit = size.Bytes, size.Kibibytes
+	if blockSize != "" {
+		u, err := size.ParseBlockSize(blockSize)
+		if err != nil {
+			return sizeUnit, blockUnit, err
+		}
+		sizeUnit, blockUnit = u, u
+	}
+	switch {
+	case humanReadable:
+		sizeUnit, blockUnit = size.HumanReadable, size.HumanReadable
+	case si:
+		sizeUnit, blockUnit = size.SI, size.SI
+	}
+	return sizeUnit, blockUnit, nil
+}
+
+// resolveTimeOptions sets the time variables from --time, --time-style,
+// --utc, --tz and --json-time. TIME_STYLE is used when --time-style is not
+// given.
+func resolveTimeOptions() error {
+	var err error
+	if timeField, err = timefmt.ParseWhich(timeWhich); err != nil {
+		return err
+	}
+	style := timeStyleName
+	if style == "" {
+		style = os.Getenv("TIME_STYLE")
+	}
+	if timeStyle, err = timefmt.ParseStyle(style); err != nil {
+		return err
+	}
+	if timeZone, err = timefmt.ParseLocation(useUTC, timeZoneName); err != nil {
+		return err
+	}
+	switch jsonTime {
+	case jsonTimeRFC3339, jsonTimeEpoch:
+	default:
+		return fmt.Errorf("invalid json time %q, expected %s or %s", jsonTime, jsonTimeRFC3339, jsonTimeEpoch)
+	}
+	now = time.Now().In(timeZone)
+	return nil
+}
+
+// resolveSortOptions sets sortOptions from --sort and -r. The -U, -S, -t
+// and -X shorthands override --sort, in that order of precedence.
+func resolveSortOptions() error {
+	key, err := order.ParseKey(sortKey)
+	if err != nil {
+		return err
+	}
+	switch {
+	case sortNone:
+		key = order.None
+	case sortSize:
+		key = order.Size
+	case sortTime:
+		key = order.Time
+	case sortExtension:
+		key = order.Extension
+	}
+	sortOptions = order.Options{Key: key, Reverse: reverseSort, Time: entryTime}
+	return nil
+}
+
+// findPredicates lists the predicates with a flag of their own, in the order
+// they are combined.
+var findPredicates = []string{"type", "name", "iname", "regex", "size", "mtime", "newer", "user", "group", "perm", "links", "kind"}
+
+// resolveFilter compiles the find-style flags, all of which must hold,
+// --filter and --where into the walker's filter. Only the find-style
+// expression can prune; --where just selects entries. Selecting by content
+// turns on --mime, which the kind and mime_type fields come from.
+func resolveFilter() error {
+	var tokens []string
+	for _, predicate := range findPredicates {
+		if v := *findFlags[predicate]; v != "" {
+			tokens = append(tokens, predicate, v)
+		}
+	}
+	if findEmpty {
+		tokens = append(tokens, "empty")
+	}
+	if filterExpr != "" {
+		expr, err := filter.Split(filterExpr)
+		if err != nil {
+			return err
+		}
+		tokens = append(append(append(tokens, "("), expr...), ")")
+	}
+	var (
+		expr *filter.Expr
+		find func(stat.CommonStat) (match, descend bool)
+	)
+	if len(tokens) > 0 {
+		var err error
+		if expr, err = filter.Parse(tokens); err != nil {
+			return err
+		}
+		find = expr.Match
+	}
+	var where *query.Query
+	if whereExpr != "" {
+		q, err := query.CompileWithDeps(whereExpr, now)
+		if err != nil {
+			return err
+		}
+		where = q
+	}
+	whereQuery = where
+	if expr.Uses("kind") || where.Uses("kind") || where.Uses("mime_type") {
+		mimeTypes = true
+	}
+	switch {
+	case where == nil:
+		entryFilter = find
+	case find == nil:
+		entryFilter = func(m stat.CommonStat) (bool, bool) { return where.Match(m), true }
+	default:
+		entryFilter = func(m stat.CommonStat) (bool, bool) {
+			match, descend := find(m)
+			return match && where.Match(m), descend
+		}
+	}
+	return nil
+}
+
+// resolveIgnore sets up the walker to hide the children of directories
+// matched by --ignore-vcs, --ignore or --hide. There is no -a to reveal
+// entries, so --hide behaves as --ignore does; neither applies to the
+// arguments themselves.
+func resolveIgnore() error {
+	patterns := append(append([]string(nil), ignorePatterns...), hidePatterns...)
+	for _, pattern := range patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	var vcs *ignore.Matcher
+	if ignoreVCS {
+		vcs = ignore.New()
+	}
+	if len(patterns) == 0 && vcs == nil {
+		return nil
+	}
+	walker.Ignore = func(path string, isDir bool) bool {
+		name := filepath.Base(path)
+		for _, pattern := range patterns {
+			if ok, _ := filepath.Match(pattern, name); ok {
+				return true
+			}
+		}
+		if vcs == nil {
+			return false
+		}
+		abs, err := filepath.Abs(path)
+		return err == nil && vcs.Ignored(abs, isDir)
+	}
+	return nil
+}
+
+// resolveDigests sets up checksumming for --hash, --hash-max-size,
+// --hash-cache and --hash-cache-file. No file is read when nothing shows the
+// checksums or selects on them.
+func resolveDigests() error {
+	digester, hashCache = nil, nil
+	if len(hashAlgorithms) == 0 {
+		if hashMaxSize != "" || useHashCache || hashCacheFile != "" {
+			return fmt.Errorf("--hash-max-size, --hash-cache and --hash-cache-file require --hash")
+		}
+		return nil
+	}
+	opts := digest.Options{Algorithms: hashAlgorithms}
+	if hashMaxSize != "" {
+		u, err := size.ParseBlockSize(hashMaxSize)
+		if err != nil || u.Human {
+			return fmt.Errorf("invalid hash size limit %q", hashMaxSize)
+		}
+		opts.MaxSize = u.BlockSize
+	}
+	if !fieldWanted("hashes", true) {
+		return nil
+	}
+	var err error
+	if hashCache, err = openHashCache(useHashCache, hashCacheFile); err != nil {
+		return err
+	}
+	opts.Cache = hashCache
+	digester, err = digest.New(opts)
+	return err
+}
+
+// openHashCache opens the checksum cache in file, as --hash-cache-file
+// names it, or with use, as --hash-cache sets it, the default cache file.
+// It returns nil without either.
+func openHashCache(use bool, file string) (*digest.Cache, error) {
+	if !use && file == "" {
+		return nil, nil
+	}
+	if file == "" {
+		var err error
+		if file, err = digest.DefaultCachePath(); err != nil {
+			return nil, fmt.Errorf("failed to locate the hash cache: %w", err)
+		}
+	}
+	return digest.OpenCache(file)
+}
+
+// saveHashCache writes back checksums computed during the listing.
+func saveHashCache(c *digest.Cache) {
+	if err := c.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+	}
+}
+
+// fieldWanted reports whether field, a top level JSON key of an entry, is
+// written by the output format, read by the --format template or selected
+// on with --where, so that enrichments nothing looks at can be skipped.
+// long says whether the long listing shows the field.
+func fieldWanted(field string, long bool) bool {
+	if whereQuery.Uses(field) {
+		return true
+	}
+	switch outputType {
+	case outputTypeJson, outputTypeYaml, outputTypeToml:
+		return true
+	case outputTypeCsv, outputTypeTsv:
+		return output.SelectsColumn(columns, field)
+	case outputTypeText:
+		if entryTemplate != nil {
+			return entryTemplate.Uses(field)
+		}
+		return long && listLong
+	case outputTypeTree:
+		// Trees show the long listing's columns, but no template.
+		return long && listLong
+	}
+	return false
+}
+
+// loadTemplate parses the --format or --format-file template, if any.
+func loadTemplate() error {
+	entryTemplate = nil
+	text := format
+	if formatFile != "" {
+		if format != "" {
+			return fmt.Errorf("--format and --format-file cannot be used together")
+		}
+		b, err := os.ReadFile(formatFile)
+		if err != nil {
+			return fmt.Errorf("failed to read format file: %w", err)
+		}
+		text = string(b)
+	}
+	if text == "" {
+		return nil
+	}
+	tmpl, err := output.NewTemplate(text, output.TemplateOptions{Size: sizeUnit, Colors: colors, Now: now})
+	if err != nil {
+		return err
+	}
+	entryTemplate = tmpl
+	return nil
+}
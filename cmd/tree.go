@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sochoa/go-ls/internal/output"
+	"github.com/sochoa/go-ls/internal/stat"
+)
+
+// listTree writes every match as a tree of the directories beneath it, down
+// to --depth levels.
+func listTree(matches []string) error {
+	ascii, err := asciiCharset()
+	if err != nil {
+		return err
+	}
+
+	var roots []output.Node
+	for _, match := range matches {
+		m, ok := argEntry(match)
+		if !ok {
+			continue
+		}
+		root := output.Node{Entry: m, Name: match}
+		if m.GetType() == stat.DirectoryFileType {
+			root.Children = treeChildren(m, match, 1)
+		}
+		roots = append(roots, root)
+	}
+
+	opts := output.TreeOptions{ASCII: ascii, Name: linkName}
+	if listLong {
+		opts.Columns = longColumns
+		opts.Aligns = longAligns()
+	}
+	return output.Tree(os.Stdout, roots, opts)
+}
+
+// treeChildren returns the sorted contents of dir, which is level levels
+// below root, with subdirectories expanded until --depth is reached or
+// --one-file-system stops at a mount.
+// Links to directories are not followed. A directory the filter rejects is
+// still shown when something beneath it matches, so matches keep their
+// place in the hierarchy; pruned directories are never read.
+func treeChildren(root stat.CommonStat, dir string, level int) []output.Node {
+	children, err := readDir(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading directory %s: %v\n", dir, err)
+		return nil
+	}
+	nodes := make([]output.Node, 0, len(children))
+	for _, child := range children {
+		match, descend := walker.Match(child.m)
+		node := output.Node{Entry: child.m}
+		if child.m.GetType() == stat.DirectoryFileType && descend && !walker.Crosses(root, child.m) &&
+			(treeDepth <= 0 || level < treeDepth) {
+			node.Children = treeChildren(root, filepath.Join(dir, child.name), level+1)
+		}
+		if match || len(node.Children) > 0 {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// asciiCharset reports whether tree connectors should be drawn in ASCII,
+// from --charset or, when it is not given, the locale's character set.
+func asciiCharset() (bool, error) {
+	name := charset
+	if name == "" {
+		for _, v := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+			if locale := os.Getenv(v); locale != "" {
+				_, name, _ = strings.Cut(locale, ".")
+				break
+			}
+		}
+	}
+	switch strings.ToLower(name) {
+	case "utf-8", "utf8":
+		return false, nil
+	case "ascii", "":
+		return true, nil
+	}
+	if charset == "" {
+		// An unknown locale character set may not have box drawing.
+		return true, nil
+	}
+	return false, fmt.Errorf("invalid charset %q, expected utf-8 or ascii", charset)
+}
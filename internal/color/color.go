@@ -0,0 +1,181 @@
+package color
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/sochoa/go-ls/internal/stat"
+)
+
+const (
+	ModeAuto   = "auto"
+	ModeAlways = "always"
+	ModeNever  = "never"
+)
+
+// Enabled decides whether output should be coloured for a --color mode. In
+// auto mode colour is used only on a terminal and only when NO_COLOR is
+// unset or empty (https://no-color.org).
+func Enabled(mode string, isTerminal bool, noColor string) (bool, error) {
+	switch mode {
+	case ModeAlways:
+		return true, nil
+	case ModeNever:
+		return false, nil
+	case ModeAuto, "":
+		return isTerminal && noColor == "", nil
+	default:
+		return false, fmt.Errorf("invalid color mode %q, expected auto, always or never", mode)
+	}
+}
+
+// defaultColors mirrors the GNU dircolors built-in database for the keys
+// we understand, used when LS_COLORS is unset.
+const defaultColors = "rs=0:di=01;34:ln=01;36:pi=40;33:so=01;35:bd=40;33;01:cd=40;33;01:" +
+	"or=40;31;01:ex=01;32:su=37;41:sg=30;43:tw=30;42:ow=34;42:st=37;44"
+
+// Scheme holds the SGR sequences parsed from an LS_COLORS value.
+type Scheme struct {
+	types    map[string]string
+	suffixes []pattern
+}
+
+type pattern struct {
+	glob  string
+	sgr   string
+	exact bool
+}
+
+// Parse reads an LS_COLORS value in GNU dircolors syntax: colon separated
+// `key=sgr` pairs where key is a two letter type key or a `*` glob matched
+// against the file name. An empty value selects the GNU defaults.
+func Parse(lsColors string) *Scheme {
+	if strings.TrimSpace(lsColors) == "" {
+		lsColors = defaultColors
+	}
+	s := &Scheme{types: map[string]string{}}
+	for _, entry := range strings.Split(lsColors, ":") {
+		key, sgr, ok := strings.Cut(entry, "=")
+		if !ok || key == "" {
+			continue
+		}
+		if strings.HasPrefix(key, "*") {
+			// `*.ext` is a plain suffix match; anything with further
+			// wildcards is matched as a glob against the base name.
+			glob := key[1:]
+			exact := !strings.ContainsAny(glob, "*?[")
+			if !exact {
+				glob = key
+			}
+			s.suffixes = append(s.suffixes, pattern{glob: glob, sgr: sgr, exact: exact})
+			continue
+		}
+		s.types[key] = sgr
+	}
+	return s
+}
+
+// Key returns the dircolors key used to colour m, ignoring file name globs.
+// As with GNU ls the more specific keys (tw, ow, st, su, sg, ex) are only
+// chosen when the scheme defines them; otherwise the plain type key is used.
+func (s *Scheme) Key(m stat.CommonStat) string {
+	st := m.GetStat()
+	l, ok := m.(stat.StatLink)
+	dangling := ok && l.Dangling
+
+	mode := uint32(st.Mode)
+	switch st.Type {
+	case stat.DirectoryFileType:
+		sticky := mode&syscall.S_ISVTX != 0
+		otherWritable := st.Permissions.Symbolic.Other.Write
+		switch {
+		case sticky && otherWritable && s.has("tw"):
+			return "tw"
+		case otherWritable && s.has("ow"):
+			return "ow"
+		case sticky && s.has("st"):
+			return "st"
+		}
+		return "di"
+	case stat.SymbolicLinkFileType:
+		if dangling && s.has("or") {
+			return "or"
+		}
+		return "ln"
+	case stat.FifoFileType:
+		return "pi"
+	case stat.SocketFileType:
+		return "so"
+	case stat.BlockDeviceFileType:
+		return "bd"
+	case stat.CharDeviceFileType:
+		return "cd"
+	case stat.RegularFileType:
+		switch {
+		case mode&syscall.S_ISUID != 0 && s.has("su"):
+			return "su"
+		case mode&syscall.S_ISGID != 0 && s.has("sg"):
+			return "sg"
+		case mode&0o111 != 0 && s.has("ex"):
+			return "ex"
+		}
+		return "fi"
+	}
+	return "no"
+}
+
+// For returns the SGR sequence for m, or "" when it should not be coloured.
+// File name globs only apply to regular files without a colour of their own.
+func (s *Scheme) For(m stat.CommonStat, name string) string {
+	key := s.Key(m)
+	if key == "fi" {
+		if sgr, ok := s.match(name); ok {
+			return sgr
+		}
+	}
+	return s.types[key]
+}
+
+func (s *Scheme) has(key string) bool {
+	sgr, ok := s.types[key]
+	return ok && sgr != "" && sgr != "0" && sgr != "00"
+}
+
+// Missing returns the SGR sequence for the target of a dangling link.
+func (s *Scheme) Missing() string {
+	if sgr, ok := s.types["mi"]; ok {
+		return sgr
+	}
+	return s.types["or"]
+}
+
+func (s *Scheme) match(name string) (string, bool) {
+	base := filepath.Base(name)
+	// Later entries win, as with GNU ls.
+	for i := len(s.suffixes) - 1; i >= 0; i-- {
+		p := s.suffixes[i]
+		if p.exact && strings.HasSuffix(strings.ToLower(base), strings.ToLower(p.glob)) {
+			return p.sgr, true
+		}
+		if !p.exact {
+			if ok, _ := filepath.Match(p.glob, base); ok {
+				return p.sgr, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Paint wraps text in sgr. A nil scheme or empty sgr leaves text untouched.
+func (s *Scheme) Paint(sgr, text string) string {
+	if s == nil || sgr == "" || text == "" {
+		return text
+	}
+	reset, ok := s.types["rs"]
+	if !ok {
+		reset = "0"
+	}
+	return "\x1b[" + sgr + "m" + text + "\x1b[" + reset + "m"
+}
@@ -0,0 +1,105 @@
+package color
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/sochoa/go-ls/internal/stat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func entry(fileType string, mode uint16) stat.Stat {
+	var s stat.Stat
+	s.Type = fileType
+	s.Mode = mode
+	s.Permissions.Symbolic.Other.Write = mode&0o002 != 0
+	return s
+}
+
+func TestEnabled(t *testing.T) {
+	tests := []struct {
+		mode     string
+		terminal bool
+		noColor  string
+		expected bool
+	}{
+		{ModeAlways, false, "", true},
+		{ModeAlways, true, "1", true},
+		{ModeNever, true, "", false},
+		{ModeAuto, true, "", true},
+		{ModeAuto, false, "", false},
+		{ModeAuto, true, "1", false},
+	}
+	for _, tt := range tests {
+		result, err := Enabled(tt.mode, tt.terminal, tt.noColor)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, result, "%+v", tt)
+	}
+
+	_, err := Enabled("sometimes", true, "")
+	require.Error(t, err)
+}
+
+func TestSchemeKey(t *testing.T) {
+	s := Parse("")
+	tests := []struct {
+		name     string
+		entry    stat.CommonStat
+		expected string
+	}{
+		{"directory", entry(stat.DirectoryFileType, 0o755), "di"},
+		{"sticky other-writable", entry(stat.DirectoryFileType, syscall.S_ISVTX|0o777), "tw"},
+		{"other-writable", entry(stat.DirectoryFileType, 0o777), "ow"},
+		{"sticky", entry(stat.DirectoryFileType, syscall.S_ISVTX|0o755), "st"},
+		{"link", stat.StatLink{Stat: entry(stat.SymbolicLinkFileType, 0o777)}, "ln"},
+		{"orphan", stat.StatLink{Stat: entry(stat.SymbolicLinkFileType, 0o777), Dangling: true}, "or"},
+		{"fifo", entry(stat.FifoFileType, 0o644), "pi"},
+		{"socket", entry(stat.SocketFileType, 0o644), "so"},
+		{"block", entry(stat.BlockDeviceFileType, 0o644), "bd"},
+		{"char", entry(stat.CharDeviceFileType, 0o644), "cd"},
+		{"setuid", entry(stat.RegularFileType, syscall.S_ISUID|0o755), "su"},
+		{"setgid", entry(stat.RegularFileType, syscall.S_ISGID|0o755), "sg"},
+		{"executable", entry(stat.RegularFileType, 0o755), "ex"},
+		{"file", entry(stat.RegularFileType, 0o644), "fi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, s.Key(tt.entry))
+		})
+	}
+}
+
+func TestSchemeFor(t *testing.T) {
+	s := Parse("di=01;34:ln=01;36:ex=01;32:*.tar=01;31:*.TGZ=01;33:*README*=04")
+
+	assert.Equal(t, "01;34", s.For(entry(stat.DirectoryFileType, 0o755), "src.tar"))
+	assert.Equal(t, "01;31", s.For(entry(stat.RegularFileType, 0o644), "/tmp/a.tar"))
+	assert.Equal(t, "01;33", s.For(entry(stat.RegularFileType, 0o644), "a.tgz"))
+	assert.Equal(t, "04", s.For(entry(stat.RegularFileType, 0o644), "README.md"))
+	assert.Equal(t, "01;32", s.For(entry(stat.RegularFileType, 0o755), "run.tar"))
+	assert.Equal(t, "", s.For(entry(stat.RegularFileType, 0o644), "main.go"))
+	assert.Equal(t, "", s.For(entry(stat.FifoFileType, 0o644), "pipe.tar"))
+
+	// Keys the scheme leaves out fall back to the plain type.
+	assert.Equal(t, "01;34", s.For(entry(stat.DirectoryFileType, syscall.S_ISVTX|0o777), "tmp"))
+	assert.Equal(t, "01;31", s.For(entry(stat.RegularFileType, syscall.S_ISUID|0o644), "a.tar"))
+
+	// Without an `or` entry dangling links fall back to the link colour.
+	orphan := stat.StatLink{Stat: entry(stat.SymbolicLinkFileType, 0o777), Dangling: true}
+	assert.Equal(t, "01;36", s.For(orphan, "broken"))
+}
+
+func TestParseDefaults(t *testing.T) {
+	s := Parse("")
+	orphan := stat.StatLink{Stat: entry(stat.SymbolicLinkFileType, 0o777), Dangling: true}
+	assert.Equal(t, "40;31;01", s.For(orphan, "broken"))
+	assert.Equal(t, "40;31;01", s.Missing())
+	assert.Equal(t, "\x1b[01;34mdir\x1b[0m", s.Paint(s.For(entry(stat.DirectoryFileType, 0o755), "dir"), "dir"))
+}
+
+func TestPaintWithoutColor(t *testing.T) {
+	var s *Scheme
+	assert.Equal(t, "name", s.Paint("01;34", "name"))
+	assert.Equal(t, "name", Parse("").Paint("", "name"))
+}
@@ -0,0 +1,153 @@
+package digest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Key identifies a version of a file: the same device, inode, modification
+// time and size are taken to mean the same content.
+type Key struct {
+	Dev, Inode uint64
+	ModTime    int64 // nanoseconds since the Unix epoch
+	Size       int64
+}
+
+func (k Key) String() string {
+	return fmt.Sprintf("%d:%d:%d:%d", k.Dev, k.Inode, k.ModTime, k.Size)
+}
+
+// Cache keeps digests across runs in a JSON file. A nil *Cache caches
+// nothing.
+type Cache struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]map[string]string
+	dirty   bool
+}
+
+// DefaultCachePath is the cache file used when --hash-cache is given
+// without one, under the user's cache directory.
+func DefaultCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "go-ls", "hashes.json"), nil
+}
+
+// OpenCache loads the cache at path. A missing file is an empty cache.
+func OpenCache(path string) (*Cache, error) {
+	c := &Cache{path: path, entries: map[string]map[string]string{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hash cache: %w", err)
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		return nil, fmt.Errorf("failed to parse hash cache %s: %w", path, err)
+	}
+	return c, nil
+}
+
+func (c *Cache) lookup(k Key) map[string]string {
+	if c == nil || k.Inode == 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return maps.Clone(c.entries[k.String()])
+}
+
+func (c *Cache) store(k Key, sums map[string]string) {
+	if c == nil || k.Inode == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := c.entries[k.String()]
+	if entry == nil {
+		entry = map[string]string{}
+		c.entries[k.String()] = entry
+	}
+	for name, sum := range sums {
+		entry[name] = sum
+	}
+	c.dirty = true
+}
+
+// Save writes the cache back if anything was added, replacing the file
+// atomically. Only the newest entry for each device and inode is kept.
+func (c *Cache) Save() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+	c.prune()
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("failed to write hash cache: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), ".hashes-*")
+	if err != nil {
+		return fmt.Errorf("failed to write hash cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write hash cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write hash cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to write hash cache: %w", err)
+	}
+	c.dirty = false
+	return nil
+}
+
+// prune keeps only the newest entry for each device and inode, so that a
+// file rewritten many times does not grow the cache.
+func (c *Cache) prune() {
+	newest := map[string]int64{}
+	for key := range c.entries {
+		file, mtime, ok := splitKey(key)
+		if ok && mtime > newest[file] {
+			newest[file] = mtime
+		}
+	}
+	for key := range c.entries {
+		if file, mtime, ok := splitKey(key); !ok || mtime < newest[file] {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// splitKey returns the device and inode part of a key and its mtime.
+func splitKey(key string) (string, int64, bool) {
+	parts := strings.Split(key, ":")
+	if len(parts) != 4 {
+		return "", 0, false
+	}
+	mtime, err := strconv.ParseInt(parts[2], 10, 64)
+	return parts[0] + ":" + parts[1], mtime, err == nil
+}
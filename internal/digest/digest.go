@@ -0,0 +1,154 @@
+// Package digest computes checksums of regular files, in parallel and with
+// an optional cache of earlier results.
+package digest
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/cespare/xxhash/v2"
+	"golang.org/x/crypto/blake2b"
+)
+
+// algorithms are the supported digests by name. blake2b is BLAKE2b-512, as
+// written by b2sum.
+var algorithms = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha1":   sha1.New,
+	"md5":    md5.New,
+	"blake2b": func() hash.Hash {
+		h, _ := blake2b.New512(nil)
+		return h
+	},
+	"xxh64":  func() hash.Hash { return xxhash.New() },
+	"crc32c": func() hash.Hash { return crc32.New(crc32.MakeTable(crc32.Castagnoli)) },
+}
+
+// Algorithms returns the names of the supported algorithms, sorted.
+func Algorithms() []string {
+	names := make([]string, 0, len(algorithms))
+	for name := range algorithms {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Options configure a Digester.
+type Options struct {
+	Algorithms []string
+	// MaxSize skips files larger than this many bytes; 0 means no limit.
+	MaxSize int64
+	// Workers bounds the files read at once; 0 means GOMAXPROCS.
+	Workers int
+	// Cache, if set, is consulted before reading a file and updated after.
+	Cache *Cache
+}
+
+// Digester computes the selected digests of files.
+type Digester struct {
+	opts Options
+}
+
+func New(opts Options) (*Digester, error) {
+	if len(opts.Algorithms) == 0 {
+		return nil, fmt.Errorf("no hash algorithm selected")
+	}
+	for _, name := range opts.Algorithms {
+		if _, ok := algorithms[name]; !ok {
+			return nil, fmt.Errorf("unknown hash algorithm %q, expected one of %s", name, strings.Join(Algorithms(), ", "))
+		}
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.GOMAXPROCS(0)
+	}
+	return &Digester{opts: opts}, nil
+}
+
+// Sum returns the hex digests of the file at path by algorithm. Files that
+// are not regular, or are over the size limit, have no digests.
+func (d *Digester) Sum(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !fi.Mode().IsRegular() || d.opts.MaxSize > 0 && fi.Size() > d.opts.MaxSize {
+		return nil, nil
+	}
+
+	key := Key{Size: fi.Size(), ModTime: fi.ModTime().UnixNano()}
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		key.Dev, key.Inode = uint64(st.Dev), uint64(st.Ino)
+	}
+	sums := map[string]string{}
+	var missing []string
+	cached := d.opts.Cache.lookup(key)
+	for _, name := range d.opts.Algorithms {
+		if sum, ok := cached[name]; ok {
+			sums[name] = sum
+		} else {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) == 0 {
+		return sums, nil
+	}
+
+	hashes := make([]hash.Hash, len(missing))
+	writers := make([]io.Writer, len(missing))
+	for i, name := range missing {
+		hashes[i] = algorithms[name]()
+		writers[i] = hashes[i]
+	}
+	if _, err := io.Copy(io.MultiWriter(writers...), f); err != nil {
+		return nil, err
+	}
+	computed := map[string]string{}
+	for i, name := range missing {
+		computed[name] = hex.EncodeToString(hashes[i].Sum(nil))
+		sums[name] = computed[name]
+	}
+	d.opts.Cache.store(key, computed)
+	return sums, nil
+}
+
+// SumAll runs Sum over paths with at most Workers files open at once.
+// Results and errors are in the order of paths.
+func (d *Digester) SumAll(paths []string) ([]map[string]string, []error) {
+	sums := make([]map[string]string, len(paths))
+	errs := make([]error, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(d.opts.Workers, len(paths)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				sums[i], errs[i] = d.Sum(paths[i])
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return sums, errs
+}
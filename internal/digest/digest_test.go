@@ -0,0 +1,115 @@
+package digest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSum(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hello.txt")
+	require.NoError(t, os.WriteFile(path, []byte("hello\n"), 0o644))
+
+	d, err := New(Options{Algorithms: Algorithms()})
+	require.NoError(t, err)
+	sums, err := d.Sum(path)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"sha256":  "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+		"sha1":    "f572d396fae9206628714fb2ce00f72e94f2258f",
+		"md5":     "b1946ac92492d2347c6235b4d2611184",
+		"blake2b": "f60ce482e5cc1229f39d71313171a8d9f4ca3a87d066bf4b205effb528192a75f14f3271e2c1a90e1de53f275b4d4793eef2f5e31ea90d2ce29d2e481c36435f",
+		"xxh64":   "e4c191d091bd8853",
+		"crc32c":  "353dd8be",
+	}, sums)
+
+	sums, err = d.Sum(dir)
+	require.NoError(t, err)
+	require.Nil(t, sums)
+
+	d, err = New(Options{Algorithms: []string{"md5"}, MaxSize: 5})
+	require.NoError(t, err)
+	sums, err = d.Sum(path)
+	require.NoError(t, err)
+	require.Nil(t, sums)
+
+	_, err = d.Sum(filepath.Join(dir, "missing"))
+	require.Error(t, err)
+
+	_, err = New(Options{Algorithms: []string{"sha3"}})
+	require.ErrorContains(t, err, `unknown hash algorithm "sha3"`)
+}
+
+func TestSumAll(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for i := range 20 {
+		path := filepath.Join(dir, fmt.Sprintf("f%d", i))
+		require.NoError(t, os.WriteFile(path, []byte(fmt.Sprint(i)), 0o644))
+		paths = append(paths, path)
+	}
+	paths = append(paths, filepath.Join(dir, "missing"))
+
+	d, err := New(Options{Algorithms: []string{"crc32c"}, Workers: 3})
+	require.NoError(t, err)
+	sums, errs := d.SumAll(paths)
+	for i := range 20 {
+		require.NoError(t, errs[i])
+		want, err := d.Sum(paths[i])
+		require.NoError(t, err)
+		require.Equal(t, want, sums[i])
+	}
+	require.Error(t, errs[20])
+}
+
+func TestCache(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data")
+	require.NoError(t, os.WriteFile(path, []byte("one"), 0o644))
+	cachePath := filepath.Join(dir, "cache", "hashes.json")
+
+	cache, err := OpenCache(cachePath)
+	require.NoError(t, err)
+	d, err := New(Options{Algorithms: []string{"sha1"}, Cache: cache})
+	require.NoError(t, err)
+	first, err := d.Sum(path)
+	require.NoError(t, err)
+	require.NoError(t, cache.Save())
+
+	// Poison the cached digest to show that the second run reads it.
+	cache, err = OpenCache(cachePath)
+	require.NoError(t, err)
+	require.Len(t, cache.entries, 1)
+	for key := range cache.entries {
+		cache.entries[key]["sha1"] = "cached"
+	}
+	d, err = New(Options{Algorithms: []string{"sha1", "md5"}, Cache: cache})
+	require.NoError(t, err)
+	sums, err := d.Sum(path)
+	require.NoError(t, err)
+	require.Equal(t, "cached", sums["sha1"])
+	require.NotEqual(t, first["sha1"], sums["sha1"])
+	require.Len(t, sums["md5"], 32)
+
+	// Rewriting the file changes its key, and saving drops the old entry.
+	require.NoError(t, os.WriteFile(path, []byte("two, longer"), 0o644))
+	sums, err = d.Sum(path)
+	require.NoError(t, err)
+	require.Len(t, sums["sha1"], 40)
+	require.NotEqual(t, "cached", sums["sha1"])
+	require.NoError(t, cache.Save())
+	cache, err = OpenCache(cachePath)
+	require.NoError(t, err)
+	require.Len(t, cache.entries, 1)
+
+	require.NoError(t, os.WriteFile(cachePath, []byte("{"), 0o644))
+	_, err = OpenCache(cachePath)
+	require.ErrorContains(t, err, "failed to parse hash cache")
+
+	var none *Cache
+	require.NoError(t, none.Save())
+}
@@ -0,0 +1,153 @@
+// Package du totals the apparent and allocated sizes of directory trees, as
+// du(1) does.
+package du
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/sochoa/go-ls/internal/size"
+	"github.com/sochoa/go-ls/internal/stat"
+	"github.com/sochoa/go-ls/internal/walk"
+)
+
+// Usage totals the space taken by an entry and everything beneath it,
+// counting each inode once.
+type Usage struct {
+	ApparentBytes  int64  `json:"apparent_bytes"`
+	AllocatedBytes int64  `json:"allocated_bytes"`
+	Inodes         uint64 `json:"inodes"`
+}
+
+// Dir is the usage of one walked root or directory beneath it. It encodes
+// as the directory's entry with its usage added as disk_usage.
+type Dir struct {
+	stat.Stat
+	DiskUsage Usage `json:"disk_usage"`
+	// Path is the path the directory was walked under.
+	Path string `json:"-"`
+	// Depth is how many levels below its root the directory is.
+	Depth int `json:"-"`
+}
+
+var _ stat.CommonStat = Dir{}
+
+func (d Dir) Json(pretty bool) (string, error) {
+	var (
+		b   []byte
+		err error
+	)
+	if pretty {
+		b, err = json.MarshalIndent(d, "", "  ")
+	} else {
+		b, err = json.Marshal(d)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal disk usage of %s to json: %w", d.AbsolutePath, err)
+	}
+	return string(b), nil
+}
+
+type inode struct {
+	device stat.DeviceID
+	number uint64
+}
+
+// open is a directory whose entries are still being counted.
+type open struct {
+	path  string
+	entry stat.CommonStat
+	usage Usage
+}
+
+// Counter sums the entries of walks up their directory trees. Hard linked
+// files are counted once across every walk. Directories the walker's
+// OneFileSystem keeps out of are not counted, as with du -x. The zero value
+// is ready to use.
+type Counter struct {
+	seen  map[inode]bool
+	stack []*open
+	root  stat.CommonStat
+	done  []Dir
+}
+
+// Walk counts root and everything beneath it with w. Errors reading an
+// entry are handed to onError and do not stop the walk.
+func (c *Counter) Walk(w walk.Walker, root string, onError func(path string, err error)) error {
+	defer func() {
+		for len(c.stack) > 0 {
+			c.pop()
+		}
+	}()
+	return w.Walk(root, func(path string, m stat.CommonStat, err error) error {
+		if err != nil {
+			onError(path, err)
+			return nil
+		}
+		if !c.add(w, path, m) {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
+// Dirs returns every root and directory counted so far, each after the
+// directories beneath it.
+func (c *Counter) Dirs() []Dir {
+	return c.done
+}
+
+// add counts m, found at path after its parent directory. It reports false
+// for a directory w does not descend into, which is not counted either.
+func (c *Counter) add(w walk.Walker, path string, m stat.CommonStat) bool {
+	path = filepath.Clean(path)
+	for len(c.stack) > 1 && c.stack[len(c.stack)-1].path != filepath.Dir(path) {
+		c.pop()
+	}
+	s := m.GetStat()
+	isDir := m.GetType() == stat.DirectoryFileType
+	if len(c.stack) == 0 {
+		c.root = m
+	} else if w.Crosses(c.root, m) {
+		return false
+	}
+
+	var usage Usage
+	id := inode{s.Device, s.Inode}
+	if isDir || s.HardLinkReferenceCount < 2 || !c.seen[id] {
+		usage = Usage{
+			ApparentBytes:  s.SizeBytes,
+			AllocatedBytes: size.Allocated(s.NumBlocks),
+			Inodes:         1,
+		}
+		if !isDir && s.HardLinkReferenceCount > 1 {
+			if c.seen == nil {
+				c.seen = map[inode]bool{}
+			}
+			c.seen[id] = true
+		}
+	}
+	if isDir || len(c.stack) == 0 {
+		c.stack = append(c.stack, &open{path: path, entry: m, usage: usage})
+		return true
+	}
+	add(&c.stack[len(c.stack)-1].usage, usage)
+	return true
+}
+
+// pop closes the innermost open directory and adds its total to its parent.
+func (c *Counter) pop() {
+	top := c.stack[len(c.stack)-1]
+	c.stack = c.stack[:len(c.stack)-1]
+	if len(c.stack) > 0 {
+		add(&c.stack[len(c.stack)-1].usage, top.usage)
+	}
+	c.done = append(c.done, Dir{Stat: top.entry.GetStat(), DiskUsage: top.usage, Path: top.path, Depth: len(c.stack)})
+}
+
+func add(total *Usage, u Usage) {
+	total.ApparentBytes += u.ApparentBytes
+	total.AllocatedBytes += u.AllocatedBytes
+	total.Inodes += u.Inodes
+}
@@ -0,0 +1,101 @@
+package du
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sochoa/go-ls/internal/size"
+	"github.com/sochoa/go-ls/internal/stat"
+	"github.com/sochoa/go-ls/internal/walk"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounterWalk(t *testing.T) {
+	root := t.TempDir()
+	path := func(name string) string { return filepath.Join(root, name) }
+	require.NoError(t, os.MkdirAll(path("sub/deep"), 0o755))
+	require.NoError(t, os.WriteFile(path("a"), make([]byte, 100), 0o644))
+	require.NoError(t, os.WriteFile(path("sub/b"), make([]byte, 200), 0o644))
+	require.NoError(t, os.Link(path("a"), path("sub/c")))
+	require.NoError(t, os.WriteFile(path("sub/deep/d"), make([]byte, 300), 0o644))
+
+	// usage totals the given entries the way the counter should.
+	usage := func(names ...string) Usage {
+		var u Usage
+		for _, name := range names {
+			m, err := walk.Entry(path(name))
+			require.NoError(t, err)
+			s := m.GetStat()
+			u.ApparentBytes += s.SizeBytes
+			u.AllocatedBytes += size.Allocated(s.NumBlocks)
+			u.Inodes++
+		}
+		return u
+	}
+
+	var c Counter
+	require.NoError(t, c.Walk(walk.Walker{}, root, func(path string, err error) {
+		t.Errorf("%s: %v", path, err)
+	}))
+	dirs := c.Dirs()
+	require.Len(t, dirs, 3)
+
+	require.Equal(t, path("sub/deep"), dirs[0].Path)
+	require.Equal(t, 2, dirs[0].Depth)
+	require.Equal(t, usage("sub/deep", "sub/deep/d"), dirs[0].DiskUsage)
+
+	// sub/c is a hard link to a, which was counted first.
+	require.Equal(t, path("sub"), dirs[1].Path)
+	require.Equal(t, 1, dirs[1].Depth)
+	require.Equal(t, usage("sub", "sub/b", "sub/deep", "sub/deep/d"), dirs[1].DiskUsage)
+
+	require.Equal(t, root, dirs[2].Path)
+	require.Equal(t, 0, dirs[2].Depth)
+	require.Equal(t, stat.DirectoryFileType, dirs[2].GetType())
+	require.Equal(t, usage(".", "a", "sub", "sub/b", "sub/deep", "sub/deep/d"), dirs[2].DiskUsage)
+
+	// A file argument is reported on its own.
+	require.NoError(t, c.Walk(walk.Walker{}, path("sub/b"), nil))
+	dirs = c.Dirs()
+	require.Len(t, dirs, 4)
+	require.Equal(t, path("sub/b"), dirs[3].Path)
+	require.Equal(t, usage("sub/b"), dirs[3].DiskUsage)
+}
+
+func TestCounterOneFileSystem(t *testing.T) {
+	entry := func(typ string, device uint32) stat.Stat {
+		var s stat.Stat
+		s.Type = typ
+		s.Device = stat.DeviceID{Major: 8, Minor: device}
+		s.SizeBytes = 10
+		s.HardLinkReferenceCount = 1
+		return s
+	}
+	for _, oneFileSystem := range []bool{false, true} {
+		var c Counter
+		w := walk.Walker{OneFileSystem: oneFileSystem}
+		require.True(t, c.add(w, "/r", entry(stat.DirectoryFileType, 1)))
+		require.Equal(t, !oneFileSystem, c.add(w, "/r/mnt", entry(stat.DirectoryFileType, 2)))
+		require.True(t, c.add(w, "/r/file", entry(stat.RegularFileType, 1)))
+		for len(c.stack) > 0 {
+			c.pop()
+		}
+		root := c.Dirs()[len(c.Dirs())-1]
+		want := uint64(2)
+		if !oneFileSystem {
+			want = 3
+		}
+		require.Equal(t, want, root.DiskUsage.Inodes)
+	}
+}
+
+func TestDirJson(t *testing.T) {
+	d := Dir{Path: "/r", Depth: 1, DiskUsage: Usage{ApparentBytes: 10, AllocatedBytes: 4096, Inodes: 2}}
+	d.BaseName = "r"
+	s, err := d.Json(false)
+	require.NoError(t, err)
+	require.Contains(t, s, `"basename":"r"`)
+	require.Contains(t, s, `"disk_usage":{"apparent_bytes":10,"allocated_bytes":4096,"inodes":2}`)
+	require.NotContains(t, s, `"depth"`)
+}
@@ -0,0 +1,224 @@
+// Package dupes finds regular files with identical content. Candidates are
+// narrowed in stages so that most files are never read in full: first by
+// size, then by a hash of their first and last blocks, and only then by a
+// hash of their whole content.
+package dupes
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/sochoa/go-ls/internal/digest"
+	"github.com/sochoa/go-ls/internal/stat"
+)
+
+// BlockSize is how much of the start and of the end of a file the partial
+// hash covers.
+const BlockSize = 4096
+
+// errChanged reports a file that changed between the stages.
+var errChanged = errors.New("file changed while being read")
+
+// Options configure a Finder.
+type Options struct {
+	// Algorithm is the digest compared in the last stage, one of
+	// digest.Algorithms(); empty means sha256.
+	Algorithm string
+	// MinSize skips files smaller than this many bytes. Empty files are
+	// always skipped.
+	MinSize int64
+	// Workers bounds the files read at once; 0 means GOMAXPROCS.
+	Workers int
+	// Cache, if set, supplies and keeps full digests of unchanged files.
+	Cache *digest.Cache
+}
+
+// Set is a group of files with the same content.
+type Set struct {
+	SizeBytes int64  `json:"size_bytes"`
+	Algorithm string `json:"algorithm"`
+	Hash      string `json:"hash"`
+	// Paths are the absolute paths of the copies, sorted.
+	Paths []string `json:"paths"`
+	// WastedBytes is the space taken by every copy but one.
+	WastedBytes int64 `json:"wasted_bytes"`
+}
+
+// Finder finds duplicate files.
+type Finder struct {
+	opts     Options
+	digester *digest.Digester
+}
+
+func New(opts Options) (*Finder, error) {
+	if opts.Algorithm == "" {
+		opts.Algorithm = "sha256"
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.GOMAXPROCS(0)
+	}
+	d, err := digest.New(digest.Options{Algorithms: []string{opts.Algorithm}, Workers: opts.Workers, Cache: opts.Cache})
+	if err != nil {
+		return nil, err
+	}
+	return &Finder{opts: opts, digester: d}, nil
+}
+
+// inode identifies a file across its hard links.
+type inode struct {
+	device stat.DeviceID
+	number uint64
+}
+
+// Find returns the sets of duplicates among files, largest waste first.
+// Entries that are not regular files are ignored, and hard links to a file
+// already seen are dropped since they share its storage. Files that cannot
+// be read are left out and reported in the errors.
+func (f *Finder) Find(files []stat.Stat) ([]Set, []error) {
+	seen := map[inode]bool{}
+	bySize := map[int64][]string{}
+	for _, s := range files {
+		if s.Type != stat.RegularFileType || s.SizeBytes == 0 || s.SizeBytes < f.opts.MinSize {
+			continue
+		}
+		id := inode{s.Device, s.Inode}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		bySize[s.SizeBytes] = append(bySize[s.SizeBytes], s.AbsolutePath)
+	}
+
+	var candidates []string
+	sizes := map[string]int64{}
+	for size, paths := range bySize {
+		if len(paths) < 2 {
+			continue
+		}
+		for _, path := range paths {
+			sizes[path] = size
+		}
+		candidates = append(candidates, paths...)
+	}
+	slices.Sort(candidates)
+
+	partials, partialErrs := f.forEach(candidates, func(path string) (string, error) {
+		return partialHash(path, sizes[path])
+	})
+	groups, errs := group(candidates, partials, partialErrs, sizes)
+	candidates = nil
+	for _, paths := range groups {
+		candidates = append(candidates, paths...)
+	}
+	slices.Sort(candidates)
+
+	sums, sumErrs := f.digester.SumAll(candidates)
+	full := make([]string, len(candidates))
+	for i, sum := range sums {
+		full[i] = sum[f.opts.Algorithm]
+		if sumErrs[i] == nil && full[i] == "" {
+			// Only files that stopped being regular have no digest.
+			sumErrs[i] = errChanged
+		}
+	}
+	groups, fullErrs := group(candidates, full, sumErrs, sizes)
+	errs = append(errs, fullErrs...)
+
+	sets := make([]Set, 0, len(groups))
+	for k, paths := range groups {
+		sets = append(sets, Set{
+			SizeBytes:   k.size,
+			Algorithm:   f.opts.Algorithm,
+			Hash:        k.hash,
+			Paths:       paths,
+			WastedBytes: k.size * int64(len(paths)-1),
+		})
+	}
+	slices.SortFunc(sets, func(a, b Set) int {
+		if c := cmp.Compare(b.WastedBytes, a.WastedBytes); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Paths[0], b.Paths[0])
+	})
+	return sets, errs
+}
+
+type groupKey struct {
+	size int64
+	hash string
+}
+
+// group collects paths by size and hash, keeping the groups of two or more
+// in the order of paths. Paths with an error are reported instead.
+func group(paths, hashes []string, hashErrs []error, sizes map[string]int64) (map[groupKey][]string, []error) {
+	var errs []error
+	groups := map[groupKey][]string{}
+	for i, path := range paths {
+		if hashErrs[i] != nil {
+			errs = append(errs, fmt.Errorf("failed to read %s: %w", path, hashErrs[i]))
+			continue
+		}
+		k := groupKey{sizes[path], hashes[i]}
+		groups[k] = append(groups[k], path)
+	}
+	for k, g := range groups {
+		if len(g) < 2 {
+			delete(groups, k)
+		}
+	}
+	return groups, errs
+}
+
+// forEach runs fn over paths with at most Workers at once. Results and
+// errors are in the order of paths.
+func (f *Finder) forEach(paths []string, fn func(path string) (string, error)) ([]string, []error) {
+	results := make([]string, len(paths))
+	errs := make([]error, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(f.opts.Workers, len(paths)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], errs[i] = fn(paths[i])
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results, errs
+}
+
+// partialHash hashes the first and last BlockSize bytes of a file of the
+// given size, which overlap or cover it all for small files.
+func partialHash(path string, size int64) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	h := xxhash.New()
+	block := make([]byte, min(size, BlockSize))
+	for _, offset := range []int64{0, size - int64(len(block))} {
+		if _, err := file.ReadAt(block, offset); err != nil {
+			if err == io.EOF {
+				err = errChanged
+			}
+			return "", err
+		}
+		h.Write(block)
+	}
+	return strconv.FormatUint(h.Sum64(), 16), nil
+}
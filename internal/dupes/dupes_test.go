@@ -0,0 +1,90 @@
+package dupes
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sochoa/go-ls/internal/stat"
+	"github.com/sochoa/go-ls/internal/walk"
+	"github.com/stretchr/testify/require"
+)
+
+// entries walks root into the stats Find takes.
+func entries(t *testing.T, root string) []stat.Stat {
+	t.Helper()
+	var files []stat.Stat
+	err := walk.Walk(root, func(path string, m stat.CommonStat, err error) error {
+		require.NoError(t, err)
+		files = append(files, m.GetStat())
+		return nil
+	})
+	require.NoError(t, err)
+	return files
+}
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	path := func(name string) string { return filepath.Join(root, name) }
+	write := func(name string, content []byte) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path(name)), 0o755))
+		require.NoError(t, os.WriteFile(path(name), content, 0o644))
+	}
+
+	big := bytes.Repeat([]byte("0123456789abcdef"), 3*BlockSize/16)
+	write("big/a", big)
+	write("big/copy/a", big)
+	write("big/c", big)
+	// Same size, first and last blocks as big: only the full hash tells
+	// it apart.
+	middle := bytes.Clone(big)
+	middle[len(middle)/2] = 'x'
+	write("big/middle", middle)
+	// Same size, different last block: dropped after the partial hash.
+	tail := bytes.Clone(big)
+	tail[len(tail)-1] = 'x'
+	write("big/tail", tail)
+	require.NoError(t, os.Link(path("big/a"), path("big/hardlink")))
+
+	write("small/x", []byte("hello\n"))
+	write("small/y", []byte("hello\n"))
+	write("small/z", []byte("howdy\n"))
+	write("empty/1", nil)
+	write("empty/2", nil)
+	require.NoError(t, os.Symlink("x", path("small/link")))
+
+	f, err := New(Options{})
+	require.NoError(t, err)
+	sets, errs := f.Find(entries(t, root))
+	require.Empty(t, errs)
+	require.Equal(t, []Set{
+		{
+			SizeBytes:   int64(len(big)),
+			Algorithm:   "sha256",
+			Hash:        "559edcd1017d3a30c4e8d793df7c982e041e10fa4d70fafb7ba395660bd72aba",
+			Paths:       []string{path("big/a"), path("big/c"), path("big/copy/a")},
+			WastedBytes: 2 * int64(len(big)),
+		},
+		{
+			SizeBytes:   6,
+			Algorithm:   "sha256",
+			Hash:        "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+			Paths:       []string{path("small/x"), path("small/y")},
+			WastedBytes: 6,
+		},
+	}, sets)
+
+	f, err = New(Options{Algorithm: "md5", MinSize: 7})
+	require.NoError(t, err)
+	sets, errs = f.Find(entries(t, root))
+	require.Empty(t, errs)
+	require.Len(t, sets, 1)
+	require.Equal(t, "md5", sets[0].Algorithm)
+	require.Len(t, sets[0].Paths, 3)
+}
+
+func TestNewUnknownAlgorithm(t *testing.T) {
+	_, err := New(Options{Algorithm: "crc16"})
+	require.Error(t, err)
+}
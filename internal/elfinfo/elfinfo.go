@@ -0,0 +1,195 @@
+// Package elfinfo describes ELF executables, shared libraries and objects:
+// their architecture, how they are linked, whether they are stripped and,
+// for Go binaries, how they were built.
+package elfinfo
+
+import (
+	"debug/buildinfo"
+	"debug/elf"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ELF is the description of one ELF file.
+type ELF struct {
+	// Class is ELF32 or ELF64.
+	Class string `json:"class"`
+	// Architecture is the machine the file targets, named as by uname -m,
+	// e.g. x86_64 or aarch64.
+	Architecture string `json:"architecture"`
+	// Type is exec, pie, dyn (a shared library), rel (an object file) or
+	// core.
+	Type string `json:"type"`
+	// Linkage is static or dynamic, for executables and shared libraries.
+	Linkage string `json:"linkage,omitempty"`
+	// Interpreter is the dynamic loader named by PT_INTERP.
+	Interpreter string `json:"interpreter,omitempty"`
+	// Needed are the DT_NEEDED libraries, in load order.
+	Needed []string `json:"needed,omitempty"`
+	// Stripped reports a file without a symbol table.
+	Stripped bool `json:"stripped"`
+	// Go is the build information embedded in Go binaries.
+	Go *GoBuild `json:"go,omitempty"`
+}
+
+// GoBuild is the build information of a Go binary, as shown by
+// go version -m.
+type GoBuild struct {
+	Version       string            `json:"version"`
+	Path          string            `json:"path,omitempty"`
+	Module        string            `json:"module,omitempty"`
+	ModuleVersion string            `json:"module_version,omitempty"`
+	Settings      map[string]string `json:"settings,omitempty"`
+}
+
+// String summarises e for the long listing as its architecture, type,
+// linkage, whether it is stripped and the Go version that built it,
+// separated by commas, e.g. x86_64,pie,dynamic,stripped.
+func (e *ELF) String() string {
+	parts := []string{e.Architecture, e.Type}
+	if e.Linkage != "" {
+		parts = append(parts, e.Linkage)
+	}
+	if e.Stripped {
+		parts = append(parts, "stripped")
+	}
+	if e.Go != nil {
+		parts = append(parts, e.Go.Version)
+	}
+	return strings.Join(parts, ",")
+}
+
+// File describes the ELF file at path. It returns nil and no error when the
+// file is not ELF.
+func File(path string) (*ELF, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Read describes the ELF file in r, or returns nil and no error when r does
+// not start with the ELF magic number.
+func Read(r io.ReaderAt) (*ELF, error) {
+	magic := make([]byte, len(elf.ELFMAG))
+	if _, err := r.ReadAt(magic, 0); err != nil || string(magic) != elf.ELFMAG {
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		return nil, nil
+	}
+	f, err := elf.NewFile(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ELF file: %w", err)
+	}
+	defer f.Close()
+
+	e := &ELF{
+		Class:        "ELF" + strings.TrimPrefix(f.Class.String(), "ELFCLASS"),
+		Architecture: architecture(f),
+		Stripped:     f.SectionByType(elf.SHT_SYMTAB) == nil,
+	}
+	for _, p := range f.Progs {
+		if p.Type != elf.PT_INTERP {
+			continue
+		}
+		b, err := io.ReadAll(p.Open())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read interpreter: %w", err)
+		}
+		e.Interpreter = strings.TrimRight(string(b), "\x00")
+	}
+	if e.Needed, err = f.ImportedLibraries(); err != nil {
+		return nil, fmt.Errorf("failed to read needed libraries: %w", err)
+	}
+	flags, err := f.DynValue(elf.DT_FLAGS_1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dynamic flags: %w", err)
+	}
+	pie := len(flags) > 0 && elf.DynFlag1(flags[0])&elf.DF_1_PIE != 0
+
+	switch f.Type {
+	case elf.ET_EXEC:
+		e.Type = "exec"
+	case elf.ET_DYN:
+		// Position independent executables are shared objects too. As in
+		// file(1), only DF_1_PIE tells them apart: some libraries, such as
+		// glibc's libc.so.6, have an interpreter so they can be run.
+		e.Type = "dyn"
+		if pie {
+			e.Type = "pie"
+		}
+	case elf.ET_REL:
+		e.Type = "rel"
+	case elf.ET_CORE:
+		e.Type = "core"
+	default:
+		e.Type = strings.ToLower(strings.TrimPrefix(f.Type.String(), "ET_"))
+	}
+	if f.Type == elf.ET_EXEC || f.Type == elf.ET_DYN {
+		e.Linkage = "static"
+		if e.Interpreter != "" || len(e.Needed) > 0 {
+			e.Linkage = "dynamic"
+		}
+	}
+
+	// Files that are not Go binaries fail to parse; that is not an error.
+	if info, err := buildinfo.Read(r); err == nil {
+		e.Go = &GoBuild{
+			Version:       info.GoVersion,
+			Path:          info.Path,
+			Module:        info.Main.Path,
+			ModuleVersion: info.Main.Version,
+		}
+		for _, s := range info.Settings {
+			if e.Go.Settings == nil {
+				e.Go.Settings = map[string]string{}
+			}
+			e.Go.Settings[s.Key] = s.Value
+		}
+	}
+	return e, nil
+}
+
+// architecture names the machine of f the way uname -m does, which
+// depends on the class and byte order for some machines.
+func architecture(f *elf.File) string {
+	wide := f.Class == elf.ELFCLASS64
+	little := f.Data == elf.ELFDATA2LSB
+	switch f.Machine {
+	case elf.EM_X86_64:
+		return "x86_64"
+	case elf.EM_386:
+		return "i386"
+	case elf.EM_AARCH64:
+		return "aarch64"
+	case elf.EM_ARM:
+		return "arm"
+	case elf.EM_RISCV:
+		return pick(wide, "riscv64", "riscv32")
+	case elf.EM_PPC64:
+		return pick(little, "ppc64le", "ppc64")
+	case elf.EM_PPC:
+		return "ppc"
+	case elf.EM_S390:
+		return pick(wide, "s390x", "s390")
+	case elf.EM_MIPS:
+		return pick(wide, "mips64", "mips") + pick(little, "el", "")
+	case elf.EM_LOONGARCH:
+		return pick(wide, "loongarch64", "loongarch32")
+	case elf.EM_SPARCV9:
+		return "sparc64"
+	}
+	return strings.ToLower(strings.TrimPrefix(f.Machine.String(), "EM_"))
+}
+
+func pick(cond bool, yes, no string) string {
+	if cond {
+		return yes
+	}
+	return no
+}
@@ -0,0 +1,193 @@
+package elfinfo
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fixture describes a minimal little-endian ELF64 file for buildELF.
+type fixture struct {
+	typ         elf.Type
+	machine     elf.Machine
+	interpreter string
+	needed      []string
+	flags1      elf.DynFlag1
+	symtab      bool
+}
+
+// buildELF lays out a header, an optional PT_INTERP program header, the
+// section contents and then the section headers.
+func buildELF(t *testing.T, fx fixture) []byte {
+	t.Helper()
+	type section struct {
+		name string
+		typ  elf.SectionType
+		link uint32
+		data []byte
+	}
+	sections := []section{{}}
+	if fx.interpreter != "" {
+		sections = append(sections, section{name: ".interp", typ: elf.SHT_PROGBITS, data: []byte(fx.interpreter + "\x00")})
+	}
+	if len(fx.needed) > 0 || fx.flags1 != 0 {
+		dynstr := []byte{0}
+		var dynamic bytes.Buffer
+		for _, lib := range fx.needed {
+			binary.Write(&dynamic, binary.LittleEndian, elf.Dyn64{Tag: int64(elf.DT_NEEDED), Val: uint64(len(dynstr))})
+			dynstr = append(dynstr, lib+"\x00"...)
+		}
+		if fx.flags1 != 0 {
+			binary.Write(&dynamic, binary.LittleEndian, elf.Dyn64{Tag: int64(elf.DT_FLAGS_1), Val: uint64(fx.flags1)})
+		}
+		binary.Write(&dynamic, binary.LittleEndian, elf.Dyn64{Tag: int64(elf.DT_NULL)})
+		sections = append(sections,
+			section{name: ".dynstr", typ: elf.SHT_STRTAB, data: dynstr},
+			section{name: ".dynamic", typ: elf.SHT_DYNAMIC, link: uint32(len(sections)), data: dynamic.Bytes()})
+	}
+	if fx.symtab {
+		sections = append(sections, section{name: ".symtab", typ: elf.SHT_SYMTAB, data: make([]byte, 24)})
+	}
+	shstrtab := []byte{0}
+	names := make([]uint32, len(sections)+1)
+	for i, s := range sections[1:] {
+		names[i+1] = uint32(len(shstrtab))
+		shstrtab = append(shstrtab, s.name+"\x00"...)
+	}
+	names[len(sections)] = uint32(len(shstrtab))
+	shstrtab = append(shstrtab, ".shstrtab\x00"...)
+	sections = append(sections, section{name: ".shstrtab", typ: elf.SHT_STRTAB, data: shstrtab})
+
+	const headerSize, progSize, sectionSize = 64, 56, 64
+	var progs uint16
+	if fx.interpreter != "" {
+		progs = 1
+	}
+	offset := uint64(headerSize + int(progs)*progSize)
+	offsets := make([]uint64, len(sections))
+	for i, s := range sections {
+		offsets[i] = offset
+		offset += uint64(len(s.data))
+	}
+
+	var buf bytes.Buffer
+	header := elf.Header64{
+		Type:      uint16(fx.typ),
+		Machine:   uint16(fx.machine),
+		Version:   uint32(elf.EV_CURRENT),
+		Ehsize:    headerSize,
+		Phentsize: progSize,
+		Phnum:     progs,
+		Shoff:     offset,
+		Shentsize: sectionSize,
+		Shnum:     uint16(len(sections)),
+		Shstrndx:  uint16(len(sections) - 1),
+	}
+	copy(header.Ident[:], elf.ELFMAG)
+	header.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	header.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	header.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+	if progs > 0 {
+		header.Phoff = headerSize
+	}
+	require.NoError(t, binary.Write(&buf, binary.LittleEndian, header))
+	if progs > 0 {
+		size := uint64(len(sections[1].data))
+		require.NoError(t, binary.Write(&buf, binary.LittleEndian, elf.Prog64{
+			Type: uint32(elf.PT_INTERP), Flags: uint32(elf.PF_R), Off: offsets[1], Filesz: size, Memsz: size, Align: 1,
+		}))
+	}
+	for _, s := range sections {
+		buf.Write(s.data)
+	}
+	for i, s := range sections {
+		require.NoError(t, binary.Write(&buf, binary.LittleEndian, elf.Section64{
+			Name: names[i], Type: uint32(s.typ), Link: s.link, Off: offsets[i], Size: uint64(len(s.data)),
+		}))
+	}
+	return buf.Bytes()
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture fixture
+		want    *ELF
+	}{
+		{
+			name: "dynamic pie",
+			fixture: fixture{typ: elf.ET_DYN, machine: elf.EM_X86_64, interpreter: "/lib64/ld-linux-x86-64.so.2",
+				needed: []string{"libm.so.6", "libc.so.6"}, flags1: elf.DF_1_NOW | elf.DF_1_PIE},
+			want: &ELF{Class: "ELF64", Architecture: "x86_64", Type: "pie", Linkage: "dynamic",
+				Interpreter: "/lib64/ld-linux-x86-64.so.2", Needed: []string{"libm.so.6", "libc.so.6"}, Stripped: true},
+		},
+		{
+			name:    "shared library",
+			fixture: fixture{typ: elf.ET_DYN, machine: elf.EM_AARCH64, needed: []string{"libc.so.6"}, symtab: true},
+			want:    &ELF{Class: "ELF64", Architecture: "aarch64", Type: "dyn", Linkage: "dynamic", Needed: []string{"libc.so.6"}},
+		},
+		{
+			name:    "static executable",
+			fixture: fixture{typ: elf.ET_EXEC, machine: elf.EM_RISCV, symtab: true},
+			want:    &ELF{Class: "ELF64", Architecture: "riscv64", Type: "exec", Linkage: "static"},
+		},
+		{
+			name:    "static pie",
+			fixture: fixture{typ: elf.ET_DYN, machine: elf.EM_PPC64, flags1: elf.DF_1_PIE},
+			want:    &ELF{Class: "ELF64", Architecture: "ppc64le", Type: "pie", Linkage: "static", Stripped: true},
+		},
+		{
+			name:    "object file",
+			fixture: fixture{typ: elf.ET_REL, machine: elf.EM_X86_64, symtab: true},
+			want:    &ELF{Class: "ELF64", Architecture: "x86_64", Type: "rel"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Read(bytes.NewReader(buildELF(t, tt.fixture)))
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	e := &ELF{Architecture: "x86_64", Type: "pie", Linkage: "dynamic", Stripped: true}
+	require.Equal(t, "x86_64,pie,dynamic,stripped", e.String())
+	e = &ELF{Architecture: "aarch64", Type: "exec", Linkage: "static", Go: &GoBuild{Version: "go1.22.1"}}
+	require.Equal(t, "aarch64,exec,static,go1.22.1", e.String())
+	require.Equal(t, "x86_64,rel", (&ELF{Architecture: "x86_64", Type: "rel"}).String())
+}
+
+func TestReadNotELF(t *testing.T) {
+	for _, content := range []string{"", "\x7fEL", "#!/bin/sh\n", "\xcf\xfa\xed\xfe\x0c\x00\x00\x01"} {
+		got, err := Read(bytes.NewReader([]byte(content)))
+		require.NoError(t, err)
+		require.Nil(t, got)
+	}
+
+	_, err := Read(bytes.NewReader([]byte("\x7fELF\x09\x01\x01")))
+	require.Error(t, err)
+}
+
+func TestFileGoBuildInfo(t *testing.T) {
+	path, err := os.Executable()
+	require.NoError(t, err)
+	got, err := File(path)
+	require.NoError(t, err)
+	if got == nil {
+		t.Skip("test binary is not ELF on this platform")
+	}
+	info, ok := debug.ReadBuildInfo()
+	require.True(t, ok)
+	require.NotNil(t, got.Go)
+	require.Equal(t, info.GoVersion, got.Go.Version)
+	require.Equal(t, info.Path, got.Go.Path)
+	require.Equal(t, runtime.GOARCH, got.Go.Settings["GOARCH"])
+}
@@ -0,0 +1,97 @@
+// Package extent maps which ranges of a file hold data and which are holes,
+// to show how sparse files are laid out.
+package extent
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// Extent is a range of a file.
+type Extent struct {
+	Offset int64 `json:"offset"`
+	Length int64 `json:"length"`
+	// Type is data or hole.
+	Type string `json:"type"`
+	// Physical is the byte offset of data on the device, and Flags the
+	// extent flags, when the file system reports them through FIEMAP,
+	// e.g. unwritten for preallocated space or shared for reflinks.
+	Physical uint64   `json:"physical,omitempty"`
+	Flags    []string `json:"flags,omitempty"`
+}
+
+const (
+	Data = "data"
+	Hole = "hole"
+)
+
+// File maps the file at path from start to end. It uses FIEMAP where the
+// system and file system support it, and SEEK_DATA and SEEK_HOLE otherwise.
+func File(path string) ([]Extent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() == 0 {
+		return nil, nil
+	}
+	data, err := fiemap(f, fi.Size())
+	if err != nil {
+		if data, err = seek(f, fi.Size()); err != nil {
+			return nil, err
+		}
+	}
+	return withHoles(data, fi.Size()), nil
+}
+
+// seek finds the data extents of f with lseek. A file system without
+// support reports the whole file as data.
+func seek(f *os.File, size int64) ([]Extent, error) {
+	fd := int(f.Fd())
+	var data []Extent
+	for pos := int64(0); pos < size; {
+		start, err := unix.Seek(fd, pos, unix.SEEK_DATA)
+		if errors.Is(err, unix.ENXIO) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		end, err := unix.Seek(fd, start, unix.SEEK_HOLE)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, Extent{Offset: start, Length: end - start, Type: Data})
+		pos = end
+	}
+	return data, nil
+}
+
+// withHoles fills the gaps between sorted data extents with holes, up to
+// size, and trims data that runs past it.
+func withHoles(data []Extent, size int64) []Extent {
+	var extents []Extent
+	pos := int64(0)
+	for _, e := range data {
+		if e.Offset >= size {
+			break
+		}
+		if e.Offset > pos {
+			extents = append(extents, Extent{Offset: pos, Length: e.Offset - pos, Type: Hole})
+		}
+		e.Length = min(e.Length, size-e.Offset)
+		extents = append(extents, e)
+		pos = e.Offset + e.Length
+	}
+	if pos < size {
+		extents = append(extents, Extent{Offset: pos, Length: size - pos, Type: Hole})
+	}
+	return extents
+}
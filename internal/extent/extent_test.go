@@ -0,0 +1,93 @@
+package extent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	fileSize   = 4 << 20
+	dataOffset = 2 << 20
+	dataSize   = 64 << 10
+)
+
+// sparseFile creates a file of fileSize bytes with data only at dataOffset,
+// skipping the test where the file system does not store holes.
+func sparseFile(t *testing.T) *os.File {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), "disk.img"))
+	require.NoError(t, err)
+	t.Cleanup(func() { f.Close() })
+	require.NoError(t, f.Truncate(fileSize))
+	_, err = f.WriteAt(make([]byte, dataSize), dataOffset)
+	require.NoError(t, err)
+	require.NoError(t, f.Sync())
+	data, err := seek(f, fileSize)
+	require.NoError(t, err)
+	if len(data) == 1 && data[0].Length == fileSize {
+		t.Skip("the file system does not support holes")
+	}
+	return f
+}
+
+// requireLayout checks that extents cover the file in order, starting and
+// ending with a hole and with data over the written range.
+func requireLayout(t *testing.T, extents []Extent) {
+	t.Helper()
+	require.NotEmpty(t, extents)
+	pos := int64(0)
+	for _, e := range extents {
+		require.Equal(t, pos, e.Offset)
+		require.Positive(t, e.Length)
+		pos += e.Length
+	}
+	require.Equal(t, int64(fileSize), pos)
+	require.Equal(t, Hole, extents[0].Type)
+	require.Equal(t, Hole, extents[len(extents)-1].Type)
+
+	covered := int64(0)
+	for _, e := range extents {
+		if e.Type == Data {
+			require.LessOrEqual(t, e.Offset, int64(dataOffset+dataSize))
+			require.GreaterOrEqual(t, e.Offset+e.Length, int64(dataOffset))
+			covered += min(e.Offset+e.Length, dataOffset+dataSize) - max(e.Offset, dataOffset)
+		}
+	}
+	require.Equal(t, int64(dataSize), covered)
+}
+
+func TestSeek(t *testing.T) {
+	f := sparseFile(t)
+	data, err := seek(f, fileSize)
+	require.NoError(t, err)
+	requireLayout(t, withHoles(data, fileSize))
+}
+
+func TestFile(t *testing.T) {
+	f := sparseFile(t)
+	extents, err := File(f.Name())
+	require.NoError(t, err)
+	requireLayout(t, extents)
+
+	empty := filepath.Join(t.TempDir(), "empty")
+	require.NoError(t, os.WriteFile(empty, nil, 0o644))
+	extents, err = File(empty)
+	require.NoError(t, err)
+	require.Empty(t, extents)
+}
+
+func TestWithHoles(t *testing.T) {
+	require.Equal(t, []Extent{{Offset: 0, Length: 10, Type: Hole}}, withHoles(nil, 10))
+	require.Equal(t, []Extent{
+		{Offset: 0, Length: 4, Type: Data},
+		{Offset: 4, Length: 2, Type: Hole},
+		{Offset: 6, Length: 4, Type: Data, Flags: []string{"unwritten"}},
+	}, withHoles([]Extent{
+		{Offset: 0, Length: 4, Type: Data},
+		{Offset: 6, Length: 4096, Type: Data, Flags: []string{"unwritten"}},
+		{Offset: 8192, Length: 4096, Type: Data},
+	}, 10))
+}
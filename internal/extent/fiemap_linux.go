@@ -0,0 +1,84 @@
+package extent
+
+import (
+	"encoding/binary"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// The FIEMAP ioctl, from linux/fiemap.h and linux/fs.h. Architectures with
+// another ioctl encoding get ENOTTY, and fall back to lseek.
+const (
+	fsIocFiemap      = 0xc020660b // _IOWR('f', 11, struct fiemap)
+	fiemapFlagSync   = 0x1
+	fiemapHeaderSize = 32
+	fiemapExtentSize = 56
+	fiemapBatch      = 128
+	fiemapExtentLast = 0x1
+)
+
+// fiemapFlags names the extent flags worth reporting.
+var fiemapFlags = []struct {
+	bit  uint32
+	name string
+}{
+	{0x2, "unknown"},
+	{0x4, "delalloc"},
+	{0x8, "encoded"},
+	{0x80, "encrypted"},
+	{0x100, "not_aligned"},
+	{0x200, "inline"},
+	{0x400, "tail"},
+	{0x800, "unwritten"},
+	{0x1000, "merged"},
+	{0x2000, "shared"},
+}
+
+// fiemap asks the file system for the data extents of f, after flushing
+// delayed allocations so that recent writes are mapped.
+func fiemap(f *os.File, size int64) ([]Extent, error) {
+	buf := make([]byte, fiemapHeaderSize+fiemapBatch*fiemapExtentSize)
+	var data []Extent
+	for start := uint64(0); start < uint64(size); {
+		clear(buf)
+		binary.NativeEndian.PutUint64(buf[0:], start)
+		binary.NativeEndian.PutUint64(buf[8:], ^uint64(0)-start)
+		binary.NativeEndian.PutUint32(buf[16:], fiemapFlagSync)
+		binary.NativeEndian.PutUint32(buf[24:], fiemapBatch)
+		_, _, errno := unix.Syscall(unix.SYS_IOCTL, f.Fd(), fsIocFiemap, uintptr(unsafe.Pointer(&buf[0])))
+		if errno != 0 {
+			return nil, errno
+		}
+		mapped := binary.NativeEndian.Uint32(buf[20:])
+		if mapped == 0 {
+			break
+		}
+		last := false
+		for i := range int(mapped) {
+			e := buf[fiemapHeaderSize+i*fiemapExtentSize:]
+			logical := binary.NativeEndian.Uint64(e[0:])
+			length := binary.NativeEndian.Uint64(e[16:])
+			flags := binary.NativeEndian.Uint32(e[40:])
+			extent := Extent{
+				Offset:   int64(logical),
+				Length:   int64(length),
+				Type:     Data,
+				Physical: binary.NativeEndian.Uint64(e[8:]),
+			}
+			for _, f := range fiemapFlags {
+				if flags&f.bit != 0 {
+					extent.Flags = append(extent.Flags, f.name)
+				}
+			}
+			data = append(data, extent)
+			start = logical + length
+			last = flags&fiemapExtentLast != 0
+		}
+		if last {
+			break
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package extent
+
+import (
+	"errors"
+	"os"
+)
+
+// fiemap is only available on Linux.
+func fiemap(f *os.File, size int64) ([]Extent, error) {
+	return nil, errors.ErrUnsupported
+}
@@ -0,0 +1,218 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sochoa/go-ls/internal/stat"
+)
+
+// Expr is a compiled find-style expression.
+type Expr struct {
+	root node
+	deps Deps
+	// used holds the names of the predicates in the expression.
+	used map[string]bool
+}
+
+// Deps are the outside lookups predicates need, injectable for tests.
+type Deps struct {
+	// Now is the reference time for mtime.
+	Now time.Time
+	// ModTime returns the modification time of a file, for newer.
+	ModTime func(path string) (time.Time, error)
+	// DirEmpty reports whether a directory has no entries, for empty.
+	DirEmpty func(path string) (bool, error)
+}
+
+// node is one operator or predicate of an expression. Predicates that
+// cannot be decided report false.
+type node interface {
+	eval(e *evaluation) bool
+}
+
+// evaluation is the state of matching one entry.
+type evaluation struct {
+	m      stat.CommonStat
+	s      stat.Stat
+	deps   Deps
+	pruned bool
+}
+
+// Match reports whether m satisfies the expression, and whether a directory
+// should be descended into. Only the prune predicate stops descent.
+func (x *Expr) Match(m stat.CommonStat) (match, descend bool) {
+	if x == nil || x.root == nil {
+		return true, true
+	}
+	e := &evaluation{m: m, s: m.GetStat(), deps: x.deps}
+	match = x.root.eval(e)
+	return match && !e.pruned, !e.pruned
+}
+
+// Uses reports whether the expression has the predicate called name, so
+// that callers can gather what it needs, such as the content kind.
+func (x *Expr) Uses(name string) bool {
+	return x != nil && x.used[name]
+}
+
+// Parse compiles the expression in tokens, as split by Split, against the
+// real file system and clock.
+func Parse(tokens []string) (*Expr, error) {
+	return ParseWithDeps(tokens, Deps{
+		Now: time.Now(),
+		ModTime: func(path string) (time.Time, error) {
+			fi, err := os.Stat(path)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return fi.ModTime(), nil
+		},
+		DirEmpty: func(path string) (bool, error) {
+			f, err := os.Open(path)
+			if err != nil {
+				return false, err
+			}
+			defer f.Close()
+			names, err := f.Readdirnames(1)
+			if errors.Is(err, io.EOF) {
+				err = nil
+			}
+			return len(names) == 0, err
+		},
+	})
+}
+
+// ParseWithDeps compiles tokens. The grammar follows find(1):
+//
+//	expr    = and { ("or" | "-o") and }
+//	and     = unary { ["and" | "-a"] unary }
+//	unary   = ("not" | "!") unary | "(" expr ")" | predicate
+//
+// Predicate names may be written bare or with one or two leading dashes,
+// e.g. name, -name or --name. An empty token list matches everything.
+func ParseWithDeps(tokens []string, deps Deps) (*Expr, error) {
+	p := &parser{tokens: tokens, deps: deps, used: map[string]bool{}}
+	x := &Expr{deps: deps, used: p.used}
+	if len(tokens) == 0 {
+		return x, nil
+	}
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in filter", p.tokens[p.pos])
+	}
+	x.root = root
+	return x, nil
+}
+
+type parser struct {
+	tokens []string
+	pos    int
+	deps   Deps
+	used   map[string]bool
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) next() (string, bool) {
+	if p.pos >= len(p.tokens) {
+		return "", false
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+	return tok, true
+}
+
+func (p *parser) or() (node, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for isOperator(p.peek(), "or", "o") {
+		p.pos++
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = or{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if tok == "" || tok == ")" || isOperator(tok, "or", "o") {
+			return left, nil
+		}
+		if isOperator(tok, "and", "a") {
+			p.pos++
+		}
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = and{left, right}
+	}
+}
+
+func (p *parser) unary() (node, error) {
+	tok, ok := p.next()
+	if !ok {
+		return nil, fmt.Errorf("filter ends where a predicate was expected")
+	}
+	switch {
+	case tok == "!" || isOperator(tok, "not", ""):
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return not{operand}, nil
+	case tok == "(":
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if tok, _ := p.next(); tok != ")" {
+			return nil, fmt.Errorf("missing ) in filter")
+		}
+		return inner, nil
+	case tok == ")":
+		return nil, fmt.Errorf("unexpected ) in filter")
+	}
+	return p.predicate(strings.TrimLeft(tok, "-"))
+}
+
+// isOperator reports whether tok is the operator name, bare or dashed, or
+// find's single letter form of it, e.g. -o for or.
+func isOperator(tok, name, letter string) bool {
+	return tok == name || tok == "-"+name || tok == "--"+name || (letter != "" && tok == "-"+letter)
+}
+
+type and [2]node
+
+func (n and) eval(e *evaluation) bool { return n[0].eval(e) && n[1].eval(e) }
+
+type or [2]node
+
+func (n or) eval(e *evaluation) bool { return n[0].eval(e) || n[1].eval(e) }
+
+type not [1]node
+
+func (n not) eval(e *evaluation) bool { return !n[0].eval(e) }
@@ -0,0 +1,194 @@
+package filter
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sochoa/go-ls/internal/sniff"
+	"github.com/sochoa/go-ls/internal/stat"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+func testDeps() Deps {
+	return Deps{
+		Now: now,
+		ModTime: func(path string) (time.Time, error) {
+			if path == "reference" {
+				return now.Add(-48 * time.Hour), nil
+			}
+			return time.Time{}, errors.New("no such file")
+		},
+		DirEmpty: func(path string) (bool, error) {
+			return path == "/src/empty", nil
+		},
+	}
+}
+
+func entry(name, typ string, size int64, mode uint16, age time.Duration) stat.Stat {
+	var s stat.Stat
+	s.BaseName = name
+	s.AbsolutePath = "/src/" + name
+	s.Type = typ
+	s.SizeBytes = size
+	s.Mode = mode
+	s.LastModifiedTime.Time = now.Add(-age)
+	s.UserID, s.UserName = 1000, "alice"
+	s.GroupID, s.GroupName = 100, "users"
+	s.HardLinkReferenceCount = 1
+	if typ == stat.RegularFileType {
+		s.Kind = sniff.Text
+	}
+	return s
+}
+
+var (
+	mainGo  = entry("main.go", stat.RegularFileType, 2048, syscall.S_IFREG|0o644, time.Hour)
+	readme  = entry("README.md", stat.RegularFileType, 0, syscall.S_IFREG|0o600, 10*24*time.Hour)
+	script  = entry("run.sh", stat.RegularFileType, 20<<20, syscall.S_IFREG|0o4755, 3*24*time.Hour)
+	vendor  = entry("vendor", stat.DirectoryFileType, 4096, syscall.S_IFDIR|0o755, time.Hour)
+	emptyD  = entry("empty", stat.DirectoryFileType, 4096, syscall.S_IFDIR|0o755, time.Hour)
+	entries = []stat.Stat{mainGo, readme, script, vendor, emptyD}
+)
+
+func matching(t *testing.T, expr string) []string {
+	t.Helper()
+	tokens, err := Split(expr)
+	require.NoError(t, err)
+	x, err := ParseWithDeps(tokens, testDeps())
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		if ok, _ := x.Match(e); ok {
+			names = append(names, e.BaseName)
+		}
+	}
+	return names
+}
+
+func TestPredicates(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{"", []string{"main.go", "README.md", "run.sh", "vendor", "empty"}},
+		{"type d", []string{"vendor", "empty"}},
+		{"-type f,d", []string{"main.go", "README.md", "run.sh", "vendor", "empty"}},
+		{"--type directory", []string{"vendor", "empty"}},
+		{"name '*.go'", []string{"main.go"}},
+		{"iname 'readme*'", []string{"README.md"}},
+		{"regex '/src/[a-z]+\\.(go|sh)'", []string{"main.go", "run.sh"}},
+		{"size +10M", []string{"run.sh"}},
+		{"size -1M", []string{"README.md"}},
+		{"size 2k", []string{"main.go"}},
+		{"size 2048c", []string{"main.go"}},
+		{"mtime -7d", []string{"main.go", "run.sh", "vendor", "empty"}},
+		{"mtime +7", []string{"README.md"}},
+		{"mtime -2h", []string{"main.go", "vendor", "empty"}},
+		{"newer reference", []string{"main.go", "vendor", "empty"}},
+		{"user alice and group 100 and type f", []string{"main.go", "README.md", "run.sh"}},
+		{"user bob", nil},
+		{"perm 644", []string{"main.go"}},
+		{"perm -4000", []string{"run.sh"}},
+		{"perm /022", nil},
+		{"perm /055", []string{"main.go", "run.sh", "vendor", "empty"}},
+		{"empty", []string{"README.md", "empty"}},
+		{"links 1", []string{"main.go", "README.md", "run.sh", "vendor", "empty"}},
+		{"links +1", nil},
+		{"kind text", []string{"main.go", "README.md", "run.sh"}},
+		{"kind script,executable", nil},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, matching(t, tt.expr), tt.expr)
+	}
+}
+
+func TestOperators(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{"type f name '*.go'", []string{"main.go"}},
+		{"type f -a ! name '*.go'", []string{"README.md", "run.sh"}},
+		{"name '*.go' or name '*.sh'", []string{"main.go", "run.sh"}},
+		{"-name '*.go' -o -name '*.sh' -o type d", []string{"main.go", "run.sh", "vendor", "empty"}},
+		{"type d or name '*.go' and size +1", []string{"main.go", "vendor", "empty"}},
+		{"(type d or name '*.go') and not empty", []string{"main.go", "vendor"}},
+		{"not (type d or size -1M)", []string{"main.go", "run.sh"}},
+		{"false or -true", []string{"main.go", "README.md", "run.sh", "vendor", "empty"}},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, matching(t, tt.expr), tt.expr)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	tokens, err := Split("name vendor prune or type d")
+	require.NoError(t, err)
+	x, err := ParseWithDeps(tokens, testDeps())
+	require.NoError(t, err)
+
+	match, descend := x.Match(vendor)
+	require.False(t, match)
+	require.False(t, descend)
+
+	match, descend = x.Match(emptyD)
+	require.True(t, match)
+	require.True(t, descend)
+
+	match, descend = x.Match(mainGo)
+	require.False(t, match)
+	require.True(t, descend)
+}
+
+func TestUses(t *testing.T) {
+	tokens, err := Split("type f and (name '*.png' or not kind image)")
+	require.NoError(t, err)
+	x, err := ParseWithDeps(tokens, testDeps())
+	require.NoError(t, err)
+	require.True(t, x.Uses("kind"))
+	require.True(t, x.Uses("name"))
+	require.False(t, x.Uses("size"))
+
+	x, err = ParseWithDeps(nil, testDeps())
+	require.NoError(t, err)
+	require.False(t, x.Uses("kind"))
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"type x",
+		"name",
+		"name '['",
+		"size 10Q",
+		"mtime yesterday",
+		"perm 999",
+		"perm u+w",
+		"links many",
+		"kind spreadsheet",
+		"newer missing",
+		"regex '('",
+		"color red",
+		"( type d",
+		"type d )",
+		"not",
+		"type d or",
+	} {
+		tokens, err := Split(expr)
+		require.NoError(t, err)
+		_, err = ParseWithDeps(tokens, testDeps())
+		require.Error(t, err, expr)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tokens, err := Split(`(name "a b" -o !name 'c'\ d)`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"(", "name", "a b", "-o", "!", "name", "c d", ")"}, tokens)
+
+	_, err = Split(`name "open`)
+	require.Error(t, err)
+}
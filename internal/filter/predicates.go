@@ -0,0 +1,281 @@
+package filter
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sochoa/go-ls/internal/sniff"
+	"github.com/sochoa/go-ls/internal/stat"
+)
+
+// typeLetters maps find's -type letters to entry types.
+var typeLetters = map[string]string{
+	"f": stat.RegularFileType,
+	"d": stat.DirectoryFileType,
+	"l": stat.SymbolicLinkFileType,
+	"b": stat.BlockDeviceFileType,
+	"c": stat.CharDeviceFileType,
+	"p": stat.FifoFileType,
+	"s": stat.SocketFileType,
+}
+
+// predicate parses the arguments of the predicate called name.
+func (p *parser) predicate(name string) (node, error) {
+	p.used[name] = true
+	switch name {
+	case "true", "false":
+		result := name == "true"
+		return predicateFunc(func(*evaluation) bool { return result }), nil
+	case "empty":
+		return predicateFunc(func(e *evaluation) bool {
+			switch e.s.Type {
+			case stat.RegularFileType:
+				return e.s.SizeBytes == 0
+			case stat.DirectoryFileType:
+				empty, err := e.deps.DirEmpty(e.s.AbsolutePath)
+				return err == nil && empty
+			}
+			return false
+		}), nil
+	case "prune":
+		return predicateFunc(func(e *evaluation) bool {
+			e.pruned = e.s.Type == stat.DirectoryFileType
+			return false
+		}), nil
+	}
+
+	arg, ok := p.next()
+	if !ok {
+		return nil, fmt.Errorf("missing argument to %s", name)
+	}
+	wrap := func(n node, err error) (node, error) {
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", name, arg, err)
+		}
+		return n, nil
+	}
+	switch name {
+	case "type":
+		return wrap(typePredicate(arg))
+	case "name", "iname":
+		return wrap(namePredicate(arg, name == "iname"))
+	case "regex":
+		re, err := regexp.Compile("^(?:" + arg + ")$")
+		return wrap(predicateFunc(func(e *evaluation) bool {
+			return re.MatchString(e.s.AbsolutePath)
+		}), err)
+	case "size":
+		return wrap(sizePredicate(arg))
+	case "mtime":
+		return wrap(mtimePredicate(arg))
+	case "newer":
+		t, err := p.deps.ModTime(arg)
+		return wrap(predicateFunc(func(e *evaluation) bool {
+			return e.s.LastModifiedTime.After(t)
+		}), err)
+	case "user":
+		return wrap(ownerPredicate(arg, func(s stat.Stat) (uint32, string) { return s.UserID, s.UserName }), nil)
+	case "group":
+		return wrap(ownerPredicate(arg, func(s stat.Stat) (uint32, string) { return s.GroupID, s.GroupName }), nil)
+	case "perm":
+		return wrap(permPredicate(arg))
+	case "kind":
+		return wrap(kindPredicate(arg))
+	case "links":
+		c, err := parseComparison(arg)
+		return wrap(predicateFunc(func(e *evaluation) bool {
+			return c.match(int64(e.s.HardLinkReferenceCount))
+		}), err)
+	}
+	return nil, fmt.Errorf("unknown filter predicate %q", name)
+}
+
+type predicateFunc func(e *evaluation) bool
+
+func (f predicateFunc) eval(e *evaluation) bool { return f(e) }
+
+// typePredicate matches a comma separated list of find's type letters or
+// entry type names such as directory.
+func typePredicate(arg string) (node, error) {
+	types := map[string]bool{}
+	for _, t := range strings.Split(arg, ",") {
+		if full, ok := typeLetters[t]; ok {
+			t = full
+		}
+		switch t {
+		case stat.RegularFileType, stat.DirectoryFileType, stat.SymbolicLinkFileType, stat.BlockDeviceFileType,
+			stat.CharDeviceFileType, stat.FifoFileType, stat.SocketFileType:
+			types[t] = true
+		default:
+			return nil, fmt.Errorf("expected f, d, l, b, c, p or s")
+		}
+	}
+	return predicateFunc(func(e *evaluation) bool {
+		return types[e.s.Type]
+	}), nil
+}
+
+// kindPredicate matches a comma separated list of content kinds, which are
+// only known for entries whose content was sniffed.
+func kindPredicate(arg string) (node, error) {
+	kinds := map[string]bool{}
+	for _, k := range strings.Split(arg, ",") {
+		if !slices.Contains(sniff.Kinds, k) {
+			return nil, fmt.Errorf("expected one of %s", strings.Join(sniff.Kinds, ", "))
+		}
+		kinds[k] = true
+	}
+	return predicateFunc(func(e *evaluation) bool {
+		return kinds[e.s.Kind]
+	}), nil
+}
+
+// namePredicate matches the base name against a shell glob.
+func namePredicate(pattern string, fold bool) (node, error) {
+	if fold {
+		pattern = strings.ToLower(pattern)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	return predicateFunc(func(e *evaluation) bool {
+		name := e.s.BaseName
+		if fold {
+			name = strings.ToLower(name)
+		}
+		ok, _ := path.Match(pattern, name)
+		return ok
+	}), nil
+}
+
+// ownerPredicate matches a user or group by name or numeric id.
+func ownerPredicate(arg string, owner func(stat.Stat) (uint32, string)) node {
+	id, err := strconv.ParseUint(arg, 10, 32)
+	numeric := err == nil
+	return predicateFunc(func(e *evaluation) bool {
+		uid, name := owner(e.s)
+		if numeric {
+			return uint64(uid) == id
+		}
+		return name == arg
+	})
+}
+
+// comparison is find's numeric argument: +N for more than N, -N for less
+// than N and N for exactly N.
+type comparison struct {
+	sign  byte
+	value int64
+}
+
+func parseComparison(arg string) (comparison, error) {
+	var c comparison
+	if strings.HasPrefix(arg, "+") || strings.HasPrefix(arg, "-") {
+		c.sign, arg = arg[0], arg[1:]
+	}
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || n < 0 {
+		return c, fmt.Errorf("expected [+-]N")
+	}
+	c.value = n
+	return c, nil
+}
+
+func (c comparison) match(n int64) bool {
+	switch c.sign {
+	case '+':
+		return n > c.value
+	case '-':
+		return n < c.value
+	}
+	return n == c.value
+}
+
+// sizeUnits are find's -size suffixes.
+var sizeUnits = map[byte]int64{
+	'c': 1,
+	'w': 2,
+	'b': 512,
+	'k': 1 << 10,
+	'K': 1 << 10,
+	'M': 1 << 20,
+	'G': 1 << 30,
+	'T': 1 << 40,
+}
+
+// sizePredicate matches sizes the way find -size does: the size is rounded
+// up to whole units, 512 byte blocks unless a suffix is given, so -1M only
+// matches empty files.
+func sizePredicate(arg string) (node, error) {
+	unit := int64(512)
+	if n := len(arg); n > 0 {
+		if u, ok := sizeUnits[arg[n-1]]; ok {
+			unit, arg = u, arg[:n-1]
+		}
+	}
+	c, err := parseComparison(arg)
+	if err != nil {
+		return nil, fmt.Errorf("expected [+-]N[cwbkMGT]")
+	}
+	return predicateFunc(func(e *evaluation) bool {
+		return c.match((e.s.SizeBytes + unit - 1) / unit)
+	}), nil
+}
+
+// ageUnits are the suffixes accepted by mtime.
+var ageUnits = map[byte]time.Duration{
+	's': time.Second,
+	'm': time.Minute,
+	'h': time.Hour,
+	'd': 24 * time.Hour,
+	'w': 7 * 24 * time.Hour,
+}
+
+// mtimePredicate matches the age of the modification time in whole units,
+// days unless a suffix is given: -7d is less than a week ago, +7d more.
+func mtimePredicate(arg string) (node, error) {
+	unit := ageUnits['d']
+	if n := len(arg); n > 0 {
+		if u, ok := ageUnits[arg[n-1]]; ok {
+			unit, arg = u, arg[:n-1]
+		}
+	}
+	c, err := parseComparison(arg)
+	if err != nil {
+		return nil, fmt.Errorf("expected [+-]N[smhdw]")
+	}
+	return predicateFunc(func(e *evaluation) bool {
+		age := e.deps.Now.Sub(e.s.LastModifiedTime.Time)
+		return c.match(int64(math.Floor(float64(age) / float64(unit))))
+	}), nil
+}
+
+// permPredicate matches permission bits as find -perm does with an octal
+// mode: exactly MODE, all of -MODE, or any of /MODE.
+func permPredicate(arg string) (node, error) {
+	var prefix byte
+	if strings.HasPrefix(arg, "-") || strings.HasPrefix(arg, "/") {
+		prefix, arg = arg[0], arg[1:]
+	}
+	mode, err := strconv.ParseUint(arg, 8, 32)
+	if err != nil || mode > 0o7777 {
+		return nil, fmt.Errorf("expected an octal mode, optionally prefixed with - or /")
+	}
+	want := uint32(mode)
+	return predicateFunc(func(e *evaluation) bool {
+		bits := uint32(e.s.Mode) & 0o7777
+		switch prefix {
+		case '-':
+			return bits&want == want
+		case '/':
+			return want == 0 || bits&want != 0
+		}
+		return bits == want
+	}), nil
+}
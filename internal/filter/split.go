@@ -0,0 +1,56 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Split breaks a --filter value into tokens the way a POSIX shell splits
+// words: on unquoted white space, with single quotes, double quotes and
+// backslashes escaping. Parentheses and ! are tokens of their own.
+func Split(s string) ([]string, error) {
+	var (
+		tokens []string
+		word   strings.Builder
+		inWord bool
+		quote  rune
+		escape bool
+	)
+	flush := func() {
+		if inWord {
+			tokens = append(tokens, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	for _, r := range s {
+		switch {
+		case escape:
+			word.WriteRune(r)
+			escape = false
+		case r == '\\' && quote != '\'':
+			escape, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			flush()
+		case r == '(' || r == ')' || (r == '!' && !inWord):
+			flush()
+			tokens = append(tokens, string(r))
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escape {
+		return nil, fmt.Errorf("unterminated quote or escape in filter %q", s)
+	}
+	flush()
+	return tokens, nil
+}
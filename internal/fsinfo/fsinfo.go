@@ -0,0 +1,59 @@
+// Package fsinfo reports the capacity of file systems, as df(1) does, from
+// statfs(2).
+package fsinfo
+
+// FileSystem is the capacity and type of a mounted file system.
+type FileSystem struct {
+	// Type names the file system, e.g. ext2/ext3/ext4 or apfs. It is empty
+	// when the magic number is not one this package knows.
+	Type string `json:"type"`
+	// Magic is the f_type magic number in hex, on systems that have one.
+	Magic string `json:"magic,omitempty"`
+	// BlockSize is the unit of the block counts.
+	BlockSize       int64  `json:"block_size"`
+	Blocks          uint64 `json:"blocks"`
+	BlocksFree      uint64 `json:"blocks_free"`
+	BlocksAvailable uint64 `json:"blocks_available"`
+	Inodes          uint64 `json:"inodes"`
+	InodesFree      uint64 `json:"inodes_free"`
+	// Flags are the mount flags, named like mount options, e.g. ro, nosuid.
+	Flags []string `json:"flags"`
+
+	// The byte counts follow from the block counts. Available space is
+	// what unprivileged users can still use, which excludes the space
+	// reserved for root.
+	TotalBytes     uint64 `json:"total_bytes"`
+	UsedBytes      uint64 `json:"used_bytes"`
+	FreeBytes      uint64 `json:"free_bytes"`
+	AvailableBytes uint64 `json:"available_bytes"`
+}
+
+// withBytes fills in the byte counts of fs from its block counts.
+func (fs *FileSystem) withBytes() *FileSystem {
+	unit := uint64(fs.BlockSize)
+	fs.TotalBytes = fs.Blocks * unit
+	fs.FreeBytes = fs.BlocksFree * unit
+	fs.AvailableBytes = fs.BlocksAvailable * unit
+	fs.UsedBytes = fs.TotalBytes - fs.FreeBytes
+	return fs
+}
+
+type flagName struct {
+	flag uint64
+	name string
+}
+
+// flagNames names the bits set in flags, in the order of names, starting
+// with ro or rw.
+func flagNames(flags, rdonly uint64, names []flagName) []string {
+	list := []string{"rw"}
+	if flags&rdonly != 0 {
+		list[0] = "ro"
+	}
+	for _, n := range names {
+		if flags&n.flag != 0 {
+			list = append(list, n.name)
+		}
+	}
+	return list
+}
@@ -0,0 +1,44 @@
+package fsinfo
+
+import "golang.org/x/sys/unix"
+
+var flags = []flagName{
+	{unix.MNT_NOSUID, "nosuid"},
+	{unix.MNT_NODEV, "nodev"},
+	{unix.MNT_NOEXEC, "noexec"},
+	{unix.MNT_SYNCHRONOUS, "sync"},
+	{unix.MNT_ASYNC, "async"},
+	{unix.MNT_NOATIME, "noatime"},
+	{unix.MNT_LOCAL, "local"},
+	{unix.MNT_QUOTA, "quota"},
+	{unix.MNT_ROOTFS, "rootfs"},
+	{unix.MNT_DONTBROWSE, "nobrowse"},
+	{unix.MNT_JOURNALED, "journaled"},
+	{unix.MNT_SNAPSHOT, "snapshot"},
+}
+
+// Stat returns the file system that path lives on. Darwin names the file
+// system type itself, so Magic is left empty.
+func Stat(path string) (*FileSystem, error) {
+	var s unix.Statfs_t
+	if err := unix.Statfs(path, &s); err != nil {
+		return nil, err
+	}
+	fs := &FileSystem{
+		Type:            unix.ByteSliceToString(s.Fstypename[:]),
+		BlockSize:       int64(s.Bsize),
+		Blocks:          s.Blocks,
+		BlocksFree:      s.Bfree,
+		BlocksAvailable: s.Bavail,
+		Inodes:          s.Files,
+		InodesFree:      s.Ffree,
+		Flags:           FlagNames(s.Flags),
+	}
+	return fs.withBytes(), nil
+}
+
+// FlagNames names the mount flags of a Darwin statfs like mount options,
+// starting with ro or rw.
+func FlagNames(f uint32) []string {
+	return flagNames(uint64(f), unix.MNT_RDONLY, flags)
+}
@@ -0,0 +1,95 @@
+package fsinfo
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// magics are the f_type values of common file systems, from statfs(2) and
+// linux/magic.h.
+var magics = map[int64]string{
+	0x0187:     "autofs",
+	0x01021994: "tmpfs",
+	0x01021997: "v9fs",
+	0x00c36400: "ceph",
+	0x1cd1:     "devpts",
+	0x2011bab0: "exfat",
+	0x27e0eb:   "cgroup",
+	0x2fc12fc1: "zfs",
+	0x3153464a: "jfs",
+	0x3434:     "nilfs",
+	0x42494e4d: "binfmt_misc",
+	0x4d44:     "vfat",
+	0x52654973: "reiserfs",
+	0x5346544e: "ntfs",
+	0x58465342: "xfs",
+	0x6165676c: "pstore",
+	0x62656572: "sysfs",
+	0x63677270: "cgroup2",
+	0x64626720: "debugfs",
+	0x65735546: "fuse",
+	0x6969:     "nfs",
+	0x6e736673: "nsfs",
+	0x73636673: "securityfs",
+	0x73717368: "squashfs",
+	0x74726163: "tracefs",
+	0x794c7630: "overlayfs",
+	0x858458f6: "ramfs",
+	0x9123683e: "btrfs",
+	0x958458f6: "hugetlbfs",
+	0x9660:     "isofs",
+	0x9fa0:     "proc",
+	0xca451a4e: "bcachefs",
+	0xcafe4a11: "bpf",
+	0xde5e81e4: "efivarfs",
+	0xe0f5e1e2: "erofs",
+	0xef53:     "ext2/ext3/ext4",
+	0xf15f:     "ecryptfs",
+	0xf2f52010: "f2fs",
+	0xfe534d42: "smb2",
+	0xff534d42: "cifs",
+}
+
+var flags = []flagName{
+	{unix.ST_NOSUID, "nosuid"},
+	{unix.ST_NODEV, "nodev"},
+	{unix.ST_NOEXEC, "noexec"},
+	{unix.ST_SYNCHRONOUS, "sync"},
+	{unix.ST_MANDLOCK, "mand"},
+	{unix.ST_NOATIME, "noatime"},
+	{unix.ST_NODIRATIME, "nodiratime"},
+	{unix.ST_RELATIME, "relatime"},
+}
+
+// Stat returns the file system that path lives on.
+func Stat(path string) (*FileSystem, error) {
+	var s unix.Statfs_t
+	if err := unix.Statfs(path, &s); err != nil {
+		return nil, err
+	}
+	return fromStatfs(&s), nil
+}
+
+func fromStatfs(s *unix.Statfs_t) *FileSystem {
+	// The widths of the statfs fields vary between architectures.
+	blockSize := int64(s.Frsize)
+	if blockSize == 0 {
+		blockSize = int64(s.Bsize)
+	}
+	// f_type is a signed word, so magics with the top bit set come back
+	// negative on 32 bit systems.
+	magic := int64(uint32(s.Type))
+	fs := &FileSystem{
+		Type:            magics[magic],
+		Magic:           fmt.Sprintf("0x%x", magic),
+		BlockSize:       blockSize,
+		Blocks:          s.Blocks,
+		BlocksFree:      s.Bfree,
+		BlocksAvailable: s.Bavail,
+		Inodes:          s.Files,
+		InodesFree:      s.Ffree,
+		Flags:           flagNames(uint64(s.Flags), unix.ST_RDONLY, flags),
+	}
+	return fs.withBytes()
+}
@@ -0,0 +1,45 @@
+package fsinfo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestFromStatfs(t *testing.T) {
+	fs := fromStatfs(&unix.Statfs_t{
+		Type:   0xef53,
+		Bsize:  65536,
+		Frsize: 4096,
+		Blocks: 1000,
+		Bfree:  300,
+		Bavail: 250,
+		Files:  64,
+		Ffree:  60,
+		Flags:  unix.ST_RDONLY | unix.ST_NODEV | unix.ST_RELATIME,
+	})
+	require.Equal(t, &FileSystem{
+		Type:            "ext2/ext3/ext4",
+		Magic:           "0xef53",
+		BlockSize:       4096,
+		Blocks:          1000,
+		BlocksFree:      300,
+		BlocksAvailable: 250,
+		Inodes:          64,
+		InodesFree:      60,
+		Flags:           []string{"ro", "nodev", "relatime"},
+		TotalBytes:      4096000,
+		UsedBytes:       2867200,
+		FreeBytes:       1228800,
+		AvailableBytes:  1024000,
+	}, fs)
+
+	// Without a fragment size the blocks are counted in f_bsize, and
+	// unknown magics are reported without a name.
+	fs = fromStatfs(&unix.Statfs_t{Type: 0x1234, Bsize: 512, Blocks: 2})
+	require.Equal(t, int64(512), fs.BlockSize)
+	require.Equal(t, uint64(1024), fs.TotalBytes)
+	require.Empty(t, fs.Type)
+	require.Equal(t, "0x1234", fs.Magic)
+}
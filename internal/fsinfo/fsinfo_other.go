@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package fsinfo
+
+import "errors"
+
+// Stat is only implemented for Linux and Darwin.
+func Stat(path string) (*FileSystem, error) {
+	return nil, errors.ErrUnsupported
+}
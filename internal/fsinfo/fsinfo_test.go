@@ -0,0 +1,28 @@
+package fsinfo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStat(t *testing.T) {
+	fs, err := Stat(t.TempDir())
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip(err)
+	}
+	require.NoError(t, err)
+	require.Positive(t, fs.BlockSize)
+	require.LessOrEqual(t, fs.BlocksFree, fs.Blocks)
+	require.LessOrEqual(t, fs.BlocksAvailable, fs.BlocksFree)
+	require.Equal(t, fs.Blocks*uint64(fs.BlockSize), fs.TotalBytes)
+	require.Equal(t, fs.TotalBytes, fs.UsedBytes+fs.FreeBytes)
+	require.Contains(t, []string{"rw", "ro"}, fs.Flags[0])
+}
+
+func TestFlagNames(t *testing.T) {
+	names := []flagName{{0x2, "nosuid"}, {0x8, "noexec"}}
+	require.Equal(t, []string{"rw"}, flagNames(0, 0x1, names))
+	require.Equal(t, []string{"ro", "nosuid", "noexec"}, flagNames(0xb, 0x1, names))
+}
@@ -0,0 +1,170 @@
+package git
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+const hashSize = sha1.Size
+
+// Hash is a SHA-1 object name.
+type Hash [hashSize]byte
+
+func ParseHash(s string) (Hash, error) {
+	var h Hash
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != hashSize {
+		return h, fmt.Errorf("invalid object name %q", s)
+	}
+	copy(h[:], b)
+	return h, nil
+}
+
+func (h Hash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+// blobHash returns the object name git gives content stored as a blob.
+func blobHash(content []byte) Hash {
+	d := sha1.New()
+	fmt.Fprintf(d, "blob %d\x00", len(content))
+	d.Write(content)
+	var h Hash
+	copy(h[:], d.Sum(nil))
+	return h
+}
+
+// IndexEntry is a file staged in the index, with the stat data git
+// recorded when it last looked at the work tree copy.
+type IndexEntry struct {
+	Path         string
+	Mode         uint32
+	Hash         Hash
+	Size         uint32
+	ModTime      time.Time
+	ChangeTime   time.Time
+	Dev, Inode   uint32
+	UID, GID     uint32
+	Stage        int
+	SkipWorktree bool
+	IntentToAdd  bool
+}
+
+const (
+	flagExtended    = 0x4000
+	flagStageMask   = 0x3000
+	flagStageShift  = 12
+	extSkipWorktree = 0x4000
+	extIntentToAdd  = 0x2000
+	entryFixedSize  = 62
+	indexHeaderSize = 12
+	indexSignature  = "DIRC"
+	minIndexVersion = 2
+	maxIndexVersion = 4
+	modeTree        = 0o40000
+	modeSymlink     = 0o120000
+	modeGitlink     = 0o160000
+	modeRegular     = 0o100644
+	modeExecutable  = 0o100755
+)
+
+// ReadIndex parses an index file of version 2, 3 or 4. Extensions are
+// skipped.
+func ReadIndex(data []byte) ([]IndexEntry, error) {
+	if len(data) < indexHeaderSize+hashSize || string(data[:4]) != indexSignature {
+		return nil, fmt.Errorf("not a git index")
+	}
+	sum := sha1.Sum(data[:len(data)-hashSize])
+	if !bytes.Equal(sum[:], data[len(data)-hashSize:]) {
+		return nil, fmt.Errorf("index checksum mismatch")
+	}
+	version := binary.BigEndian.Uint32(data[4:])
+	if version < minIndexVersion || version > maxIndexVersion {
+		return nil, fmt.Errorf("unsupported index version %d", version)
+	}
+	count := binary.BigEndian.Uint32(data[8:])
+	body := data[:len(data)-hashSize]
+	off := indexHeaderSize
+	entries := make([]IndexEntry, 0, count)
+	previous := ""
+	for range count {
+		if len(body) < off+entryFixedSize {
+			return nil, fmt.Errorf("truncated index entry")
+		}
+		b := body[off:]
+		u32 := func(i int) uint32 { return binary.BigEndian.Uint32(b[i:]) }
+		e := IndexEntry{
+			ChangeTime: time.Unix(int64(u32(0)), int64(u32(4))),
+			ModTime:    time.Unix(int64(u32(8)), int64(u32(12))),
+			Dev:        u32(16),
+			Inode:      u32(20),
+			Mode:       u32(24),
+			UID:        u32(28),
+			GID:        u32(32),
+			Size:       u32(36),
+		}
+		copy(e.Hash[:], b[40:60])
+		flags := binary.BigEndian.Uint16(b[60:])
+		e.Stage = int(flags&flagStageMask) >> flagStageShift
+		n := entryFixedSize
+		if flags&flagExtended != 0 {
+			if version < 3 || len(b) < n+2 {
+				return nil, fmt.Errorf("invalid extended index entry")
+			}
+			ext := binary.BigEndian.Uint16(b[n:])
+			e.SkipWorktree = ext&extSkipWorktree != 0
+			e.IntentToAdd = ext&extIntentToAdd != 0
+			n += 2
+		}
+
+		if version == 4 {
+			// The path drops some bytes from the end of the previous one
+			// and appends a suffix.
+			strip, used := offsetVarint(b[n:])
+			if used == 0 || strip > uint64(len(previous)) {
+				return nil, fmt.Errorf("invalid path compression in index")
+			}
+			n += used
+			end := bytes.IndexByte(b[n:], 0)
+			if end < 0 {
+				return nil, fmt.Errorf("truncated index entry")
+			}
+			e.Path = previous[:len(previous)-int(strip)] + string(b[n:n+end])
+			off += n + end + 1
+		} else {
+			end := bytes.IndexByte(b[n:], 0)
+			if end < 0 {
+				return nil, fmt.Errorf("truncated index entry")
+			}
+			e.Path = string(b[n : n+end])
+			// Entries are padded with NULs to a multiple of eight bytes.
+			off += (n + end + 8) &^ 7
+		}
+		previous = e.Path
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// offsetVarint decodes git's offset encoding, in which each continuation
+// adds one before shifting so that no value has two encodings. It returns
+// the number of bytes used, or 0 if b is truncated.
+func offsetVarint(b []byte) (uint64, int) {
+	if len(b) == 0 {
+		return 0, 0
+	}
+	v := uint64(b[0] & 0x7f)
+	i := 1
+	for b[i-1]&0x80 != 0 {
+		if i == len(b) {
+			return 0, 0
+		}
+		v = (v+1)<<7 | uint64(b[i]&0x7f)
+		i++
+	}
+	return v, i
+}
@@ -0,0 +1,133 @@
+package git
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// encodeIndex writes entries as an index file of the given version.
+func encodeIndex(version uint32, entries []IndexEntry) []byte {
+	var b bytes.Buffer
+	b.WriteString(indexSignature)
+	binary.Write(&b, binary.BigEndian, version)
+	binary.Write(&b, binary.BigEndian, uint32(len(entries)))
+	previous := ""
+	for _, e := range entries {
+		start := b.Len()
+		for _, v := range []uint32{
+			uint32(e.ChangeTime.Unix()), uint32(e.ChangeTime.Nanosecond()),
+			uint32(e.ModTime.Unix()), uint32(e.ModTime.Nanosecond()),
+			e.Dev, e.Inode, e.Mode, e.UID, e.GID, e.Size,
+		} {
+			binary.Write(&b, binary.BigEndian, v)
+		}
+		b.Write(e.Hash[:])
+		flags := uint16(e.Stage<<flagStageShift) | uint16(min(len(e.Path), 0xfff))
+		var ext uint16
+		if e.SkipWorktree {
+			ext |= extSkipWorktree
+		}
+		if e.IntentToAdd {
+			ext |= extIntentToAdd
+		}
+		if ext != 0 {
+			flags |= flagExtended
+		}
+		binary.Write(&b, binary.BigEndian, flags)
+		if ext != 0 {
+			binary.Write(&b, binary.BigEndian, ext)
+		}
+		if version == 4 {
+			common := 0
+			for common < len(previous) && common < len(e.Path) && previous[common] == e.Path[common] {
+				common++
+			}
+			b.Write(encodeOffsetVarint(uint64(len(previous) - common)))
+			b.WriteString(e.Path[common:])
+			b.WriteByte(0)
+		} else {
+			b.WriteString(e.Path)
+			n := b.Len() - start
+			b.Write(make([]byte, (n+8)&^7-n))
+		}
+		previous = e.Path
+	}
+	sum := sha1.Sum(b.Bytes())
+	b.Write(sum[:])
+	return b.Bytes()
+}
+
+func encodeOffsetVarint(v uint64) []byte {
+	b := []byte{byte(v & 0x7f)}
+	for v >>= 7; v > 0; v >>= 7 {
+		v--
+		b = append([]byte{byte(0x80 | v&0x7f)}, b...)
+	}
+	return b
+}
+
+func TestReadIndex(t *testing.T) {
+	mtime := time.Unix(1700000000, 123456789)
+	entries := []IndexEntry{
+		{Path: "README.md", Mode: modeRegular, Size: 12, ModTime: mtime, ChangeTime: mtime, Inode: 42, Hash: blobHash([]byte("hello"))},
+		{Path: "cmd/root.go", Mode: modeExecutable, Size: 300, ModTime: mtime, ChangeTime: mtime, UID: 1000, GID: 100},
+		{Path: "cmd/text.go", Mode: modeRegular, Stage: 2, ModTime: mtime, ChangeTime: mtime},
+		{Path: "internal/" + strings.Repeat("x", 200), Mode: modeSymlink, ModTime: mtime, ChangeTime: mtime},
+	}
+	for _, version := range []uint32{2, 4} {
+		got, err := ReadIndex(encodeIndex(version, entries))
+		require.NoError(t, err, "version %d", version)
+		require.Len(t, got, len(entries))
+		for i := range entries {
+			require.Equal(t, entries[i].Path, got[i].Path)
+			require.Equal(t, entries[i].Mode, got[i].Mode)
+			require.Equal(t, entries[i].Hash, got[i].Hash)
+			require.Equal(t, entries[i].Size, got[i].Size)
+			require.Equal(t, entries[i].Inode, got[i].Inode)
+			require.Equal(t, entries[i].Stage, got[i].Stage)
+			require.True(t, entries[i].ModTime.Equal(got[i].ModTime))
+		}
+	}
+
+	extended := append(entries[:1:1], IndexEntry{Path: "sparse", SkipWorktree: true}, IndexEntry{Path: "todo", IntentToAdd: true})
+	got, err := ReadIndex(encodeIndex(3, extended))
+	require.NoError(t, err)
+	require.True(t, got[1].SkipWorktree)
+	require.True(t, got[2].IntentToAdd)
+	require.Equal(t, "todo", got[2].Path)
+
+	_, err = ReadIndex(encodeIndex(2, extended))
+	require.ErrorContains(t, err, "invalid extended index entry")
+}
+
+func TestReadIndexErrors(t *testing.T) {
+	valid := encodeIndex(2, []IndexEntry{{Path: "a"}})
+
+	_, err := ReadIndex([]byte("not an index"))
+	require.ErrorContains(t, err, "not a git index")
+
+	corrupt := bytes.Clone(valid)
+	corrupt[len(corrupt)-hashSize-1] ^= 0xff
+	_, err = ReadIndex(corrupt)
+	require.ErrorContains(t, err, "checksum mismatch")
+
+	_, err = ReadIndex(encodeIndex(5, nil))
+	require.ErrorContains(t, err, "unsupported index version 5")
+}
+
+func TestOffsetVarint(t *testing.T) {
+	for _, v := range []uint64{0, 1, 127, 128, 255, 16511, 16512, 1 << 40} {
+		b := encodeOffsetVarint(v)
+		got, n := offsetVarint(b)
+		require.Equal(t, v, got)
+		require.Equal(t, len(b), n)
+	}
+	_, n := offsetVarint([]byte{0x80})
+	require.Zero(t, n)
+}
@@ -0,0 +1,284 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Object types, numbered as in pack files.
+const (
+	objCommit   = 1
+	objTree     = 2
+	objBlob     = 3
+	objTag      = 4
+	objOfsDelta = 6
+	objRefDelta = 7
+)
+
+var objTypes = map[string]int{"commit": objCommit, "tree": objTree, "blob": objBlob, "tag": objTag}
+
+// objectStore reads loose and packed objects.
+type objectStore struct {
+	dir string
+
+	once  sync.Once
+	packs []*packIndex
+	err   error
+}
+
+func (s *objectStore) read(h Hash) (int, []byte, error) {
+	typ, data, err := s.readLoose(h)
+	if !errors.Is(err, fs.ErrNotExist) {
+		return typ, data, err
+	}
+	s.once.Do(s.loadPacks)
+	if s.err != nil {
+		return 0, nil, s.err
+	}
+	for _, p := range s.packs {
+		if off, ok := p.find(h); ok {
+			return p.read(s, off)
+		}
+	}
+	return 0, nil, fmt.Errorf("object %s not found", h)
+}
+
+func (s *objectStore) readLoose(h Hash) (int, []byte, error) {
+	hex := h.String()
+	f, err := os.Open(filepath.Join(s.dir, hex[:2], hex[2:]))
+	if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+	data, err := inflate(f)
+	if err != nil {
+		return 0, nil, fmt.Errorf("object %s: %w", h, err)
+	}
+	header, content, ok := bytes.Cut(data, []byte{0})
+	name, size, ok2 := bytes.Cut(header, []byte{' '})
+	typ, known := objTypes[string(name)]
+	if !ok || !ok2 || !known || string(size) != strconv.Itoa(len(content)) {
+		return 0, nil, fmt.Errorf("object %s: invalid header", h)
+	}
+	return typ, content, nil
+}
+
+func inflate(r io.Reader) ([]byte, error) {
+	z, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer z.Close()
+	return io.ReadAll(z)
+}
+
+func (s *objectStore) loadPacks() {
+	names, err := filepath.Glob(filepath.Join(s.dir, "pack", "*.idx"))
+	if err != nil {
+		s.err = err
+		return
+	}
+	for _, name := range names {
+		p, err := readPackIndex(name)
+		if err != nil {
+			s.err = err
+			return
+		}
+		s.packs = append(s.packs, p)
+	}
+}
+
+// packIndex is a version 2 pack index and the pack it describes.
+type packIndex struct {
+	pack    string
+	fanout  [256]uint32
+	hashes  []byte
+	offsets []byte
+	large   []byte
+}
+
+const packIndexMagic = "\xfftOc"
+
+func readPackIndex(name string) (*packIndex, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	const header = 8 + 256*4
+	if len(data) < header || string(data[:4]) != packIndexMagic || binary.BigEndian.Uint32(data[4:]) != 2 {
+		return nil, fmt.Errorf("%s: unsupported pack index", name)
+	}
+	p := &packIndex{pack: name[:len(name)-len(".idx")] + ".pack"}
+	for i := range p.fanout {
+		p.fanout[i] = binary.BigEndian.Uint32(data[8+4*i:])
+	}
+	n := int(p.fanout[255])
+	hashesEnd := header + n*hashSize
+	offsetsStart := hashesEnd + n*4 // after the CRCs
+	offsetsEnd := offsetsStart + n*4
+	if len(data) < offsetsEnd {
+		return nil, fmt.Errorf("%s: truncated pack index", name)
+	}
+	p.hashes = data[header:hashesEnd]
+	p.offsets = data[offsetsStart:offsetsEnd]
+	p.large = data[offsetsEnd:]
+	return p, nil
+}
+
+func (p *packIndex) find(h Hash) (int64, bool) {
+	lo := 0
+	if h[0] > 0 {
+		lo = int(p.fanout[h[0]-1])
+	}
+	hi := int(p.fanout[h[0]])
+	i := lo + sort.Search(hi-lo, func(i int) bool {
+		return bytes.Compare(p.hashes[(lo+i)*hashSize:(lo+i+1)*hashSize], h[:]) >= 0
+	})
+	if i == hi || !bytes.Equal(p.hashes[i*hashSize:(i+1)*hashSize], h[:]) {
+		return 0, false
+	}
+	off := binary.BigEndian.Uint32(p.offsets[i*4:])
+	if off&0x80000000 == 0 {
+		return int64(off), true
+	}
+	// The offset is an index into the table of 64-bit offsets.
+	j := int(off&0x7fffffff) * 8
+	if len(p.large) < j+8 {
+		return 0, false
+	}
+	return int64(binary.BigEndian.Uint64(p.large[j:])), true
+}
+
+func (p *packIndex) read(s *objectStore, off int64) (int, []byte, error) {
+	f, err := os.Open(p.pack)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+	return p.readAt(s, f, off)
+}
+
+// readAt reads the object at off in the open pack f, resolving deltas.
+func (p *packIndex) readAt(s *objectStore, f *os.File, off int64) (int, []byte, error) {
+	r := bufio.NewReader(io.NewSectionReader(f, off, 1<<62))
+	c, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	typ := int(c>>4) & 7
+	// The size is redundant with the deflated stream, so it is skipped.
+	for c&0x80 != 0 {
+		if c, err = r.ReadByte(); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	var baseType int
+	var base []byte
+	switch typ {
+	case objOfsDelta:
+		var buf []byte
+		for {
+			c, err := r.ReadByte()
+			if err != nil {
+				return 0, nil, err
+			}
+			buf = append(buf, c)
+			if c&0x80 == 0 {
+				break
+			}
+		}
+		rel, _ := offsetVarint(buf)
+		if rel == 0 || int64(rel) > off {
+			return 0, nil, fmt.Errorf("%s: invalid delta base offset", p.pack)
+		}
+		baseType, base, err = p.readAt(s, f, off-int64(rel))
+	case objRefDelta:
+		var h Hash
+		if _, err := io.ReadFull(r, h[:]); err != nil {
+			return 0, nil, err
+		}
+		baseType, base, err = s.read(h)
+	case objCommit, objTree, objBlob, objTag:
+		data, err := inflate(r)
+		return typ, data, err
+	default:
+		return 0, nil, fmt.Errorf("%s: invalid object type %d", p.pack, typ)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	delta, err := inflate(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	data, err := applyDelta(base, delta)
+	return baseType, data, err
+}
+
+// applyDelta rebuilds an object from its base and a delta of copy and
+// insert instructions.
+func applyDelta(base, delta []byte) ([]byte, error) {
+	invalid := errors.New("invalid delta")
+	srcSize, n := binary.Uvarint(delta)
+	if n <= 0 || srcSize != uint64(len(base)) {
+		return nil, invalid
+	}
+	delta = delta[n:]
+	dstSize, n := binary.Uvarint(delta)
+	if n <= 0 {
+		return nil, invalid
+	}
+	delta = delta[n:]
+	out := make([]byte, 0, dstSize)
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+		switch {
+		case op&0x80 != 0:
+			// Copy from the base: bits 0-3 select offset bytes and bits
+			// 4-6 size bytes, least significant first.
+			var fields [7]uint64
+			for i := range fields {
+				if op&(1<<i) == 0 {
+					continue
+				}
+				if len(delta) == 0 {
+					return nil, invalid
+				}
+				fields[i], delta = uint64(delta[0]), delta[1:]
+			}
+			offset := fields[0] | fields[1]<<8 | fields[2]<<16 | fields[3]<<24
+			size := fields[4] | fields[5]<<8 | fields[6]<<16
+			if size == 0 {
+				size = 0x10000
+			}
+			if offset+size > uint64(len(base)) {
+				return nil, invalid
+			}
+			out = append(out, base[offset:offset+size]...)
+		case op != 0:
+			if int(op) > len(delta) {
+				return nil, invalid
+			}
+			out, delta = append(out, delta[:op]...), delta[op:]
+		default:
+			return nil, invalid
+		}
+	}
+	if uint64(len(out)) != dstSize {
+		return nil, invalid
+	}
+	return out, nil
+}
@@ -0,0 +1,160 @@
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func deflate(data []byte) []byte {
+	var b bytes.Buffer
+	z := zlib.NewWriter(&b)
+	z.Write(data)
+	z.Close()
+	return b.Bytes()
+}
+
+func objectHash(typ string, content []byte) Hash {
+	return Hash(sha1.Sum(append([]byte(fmt.Sprintf("%s %d\x00", typ, len(content))), content...)))
+}
+
+// packObject is an object to write into a test pack: either whole, or as a
+// delta against the object at index base.
+type packObject struct {
+	typ     int
+	content []byte
+	base    int
+	delta   []byte
+}
+
+// writePack writes a version 2 pack and index holding objects to dir and
+// returns their names.
+func writePack(t *testing.T, dir string, objects []packObject) []Hash {
+	t.Helper()
+	var pack bytes.Buffer
+	pack.WriteString("PACK")
+	binary.Write(&pack, binary.BigEndian, uint32(2))
+	binary.Write(&pack, binary.BigEndian, uint32(len(objects)))
+	offsets := make([]int64, len(objects))
+	hashes := make([]Hash, len(objects))
+	contents := make([][]byte, len(objects))
+	names := map[int]string{objCommit: "commit", objTree: "tree", objBlob: "blob"}
+	for i, o := range objects {
+		offsets[i] = int64(pack.Len())
+		data, typ := o.content, o.typ
+		if o.delta != nil {
+			data, typ = o.delta, objOfsDelta
+			var err error
+			contents[i], err = applyDelta(contents[o.base], o.delta)
+			require.NoError(t, err)
+			o.typ = objects[o.base].typ
+		} else {
+			contents[i] = o.content
+		}
+		hashes[i] = objectHash(names[o.typ], contents[i])
+
+		size := len(data)
+		c := byte(typ<<4) | byte(size&15)
+		for size >>= 4; size > 0; size >>= 7 {
+			pack.WriteByte(c | 0x80)
+			c = byte(size & 0x7f)
+		}
+		pack.WriteByte(c)
+		if o.delta != nil {
+			pack.Write(encodeOffsetVarint(uint64(offsets[i] - offsets[o.base])))
+		}
+		pack.Write(deflate(data))
+	}
+	sum := sha1.Sum(pack.Bytes())
+	pack.Write(sum[:])
+
+	order := make([]int, len(objects))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return bytes.Compare(hashes[order[a]][:], hashes[order[b]][:]) < 0 })
+	var idx bytes.Buffer
+	idx.WriteString(packIndexMagic)
+	binary.Write(&idx, binary.BigEndian, uint32(2))
+	for b := 0; b < 256; b++ {
+		n := 0
+		for _, h := range hashes {
+			if int(h[0]) <= b {
+				n++
+			}
+		}
+		binary.Write(&idx, binary.BigEndian, uint32(n))
+	}
+	for _, i := range order {
+		idx.Write(hashes[i][:])
+	}
+	idx.Write(make([]byte, 4*len(objects))) // CRCs are not checked
+	for _, i := range order {
+		binary.Write(&idx, binary.BigEndian, uint32(offsets[i]))
+	}
+
+	packDir := filepath.Join(dir, "pack")
+	require.NoError(t, os.MkdirAll(packDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(packDir, "pack-test.pack"), pack.Bytes(), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(packDir, "pack-test.idx"), idx.Bytes(), 0o644))
+	return hashes
+}
+
+func TestApplyDelta(t *testing.T) {
+	base := []byte("the quick brown fox")
+	delta := []byte{
+		byte(len(base)), 21,
+		0x90, 10, // copy 10 bytes from offset 0
+		3, 'r', 'e', 'd',
+		0x91, 15, 4, // copy 4 bytes from offset 15
+		4, ' ', 'j', 'm', 'p',
+	}
+	got, err := applyDelta(base, delta)
+	require.NoError(t, err)
+	require.Equal(t, "the quick red fox jmp", string(got))
+
+	_, err = applyDelta(base, []byte{byte(len(base)), 4, 0x91, 30, 4})
+	require.Error(t, err)
+	_, err = applyDelta(base, []byte{3, 0})
+	require.Error(t, err)
+	_, err = applyDelta(base, []byte{byte(len(base)), 1, 0})
+	require.Error(t, err)
+}
+
+func TestObjectStore(t *testing.T) {
+	dir := t.TempDir()
+	base := bytes.Repeat([]byte("line of text\n"), 20)
+	hashes := writePack(t, dir, []packObject{
+		{typ: objBlob, content: base},
+		{base: 0, delta: append(binary.AppendUvarint(binary.AppendUvarint(nil, uint64(len(base))), 130),
+			0x90, 0x80, 2, 'x', '\n')}, // 128 bytes copied and two inserted
+		{typ: objBlob, content: []byte("small")},
+	})
+	loose := []byte("loose object")
+	h := objectHash("blob", loose)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, h.String()[:2]), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, h.String()[:2], h.String()[2:]), deflate([]byte("blob 12\x00loose object")), 0o644))
+
+	s := &objectStore{dir: dir}
+	for i, want := range [][]byte{base, append(bytes.Clone(base[:128]), 'x', '\n'), []byte("small")} {
+		typ, data, err := s.read(hashes[i])
+		require.NoError(t, err)
+		require.Equal(t, objBlob, typ)
+		require.Equal(t, want, data)
+	}
+	typ, data, err := s.read(h)
+	require.NoError(t, err)
+	require.Equal(t, objBlob, typ)
+	require.Equal(t, loose, data)
+
+	_, _, err = s.read(objectHash("blob", []byte("missing")))
+	require.ErrorContains(t, err, "not found")
+}
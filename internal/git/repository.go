@@ -0,0 +1,190 @@
+// Package git reads just enough of a git repository, without the git
+// binary, to report the status of files in its work tree.
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Repository is a git work tree and the repository behind it.
+type Repository struct {
+	WorkTree string
+	// GitDir holds the index and HEAD of the work tree; CommonDir holds
+	// objects and refs, and differs from GitDir in linked work trees.
+	GitDir    string
+	CommonDir string
+
+	objects *objectStore
+}
+
+// Discover returns the repository whose work tree contains dir, or nil if
+// there is none.
+func Discover(dir string) (*Repository, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+			return Open(dir)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// Open opens the repository of the work tree at root, whose .git is either
+// the repository directory or, for linked work trees and submodules, a
+// file pointing at it.
+func Open(root string) (*Repository, error) {
+	r := &Repository{WorkTree: root, GitDir: filepath.Join(root, ".git")}
+	fi, err := os.Stat(r.GitDir)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		data, err := os.ReadFile(r.GitDir)
+		if err != nil {
+			return nil, err
+		}
+		target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+		if !ok {
+			return nil, fmt.Errorf("invalid gitfile %s", r.GitDir)
+		}
+		r.GitDir = resolve(root, strings.TrimSpace(target))
+	}
+	r.CommonDir = r.GitDir
+	if data, err := os.ReadFile(filepath.Join(r.GitDir, "commondir")); err == nil {
+		r.CommonDir = resolve(r.GitDir, strings.TrimSpace(string(data)))
+	}
+	r.objects = &objectStore{dir: filepath.Join(r.CommonDir, "objects")}
+	return r, nil
+}
+
+// head returns the commit HEAD points at, or false on an unborn branch.
+func (r *Repository) head() (Hash, bool, error) {
+	name := "HEAD"
+	for range 10 {
+		data, err := os.ReadFile(r.refPath(name))
+		if errors.Is(err, fs.ErrNotExist) {
+			return r.packedRef(name)
+		}
+		if err != nil {
+			return Hash{}, false, err
+		}
+		content := strings.TrimSpace(string(data))
+		if target, ok := strings.CutPrefix(content, "ref:"); ok {
+			name = strings.TrimSpace(target)
+			continue
+		}
+		h, err := ParseHash(content)
+		return h, err == nil, err
+	}
+	return Hash{}, false, fmt.Errorf("too many levels of symbolic refs")
+}
+
+// refPath returns the file of a loose ref. Refs outside refs/ such as HEAD
+// belong to the work tree.
+func (r *Repository) refPath(name string) string {
+	if strings.HasPrefix(name, "refs/") {
+		return filepath.Join(r.CommonDir, filepath.FromSlash(name))
+	}
+	return filepath.Join(r.GitDir, filepath.FromSlash(name))
+}
+
+func (r *Repository) packedRef(name string) (Hash, bool, error) {
+	data, err := os.ReadFile(filepath.Join(r.CommonDir, "packed-refs"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Hash{}, false, nil
+	}
+	if err != nil {
+		return Hash{}, false, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		hex, ref, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if ok && ref == name {
+			h, err := ParseHash(hex)
+			return h, err == nil, err
+		}
+	}
+	return Hash{}, false, nil
+}
+
+// headTree returns the blobs of HEAD's tree by path, empty on an unborn
+// branch.
+func (r *Repository) headTree() (map[string]treeEntry, error) {
+	files := map[string]treeEntry{}
+	commit, ok, err := r.head()
+	if err != nil || !ok {
+		return files, err
+	}
+	typ, data, err := r.objects.read(commit)
+	if err != nil {
+		return nil, err
+	}
+	if typ != objCommit {
+		return nil, fmt.Errorf("HEAD %s is not a commit", commit)
+	}
+	hex, ok := strings.CutPrefix(string(data), "tree ")
+	if !ok || len(hex) < 40 {
+		return nil, fmt.Errorf("commit %s has no tree", commit)
+	}
+	tree, err := ParseHash(hex[:40])
+	if err != nil {
+		return nil, err
+	}
+	return files, r.flattenTree(tree, "", files)
+}
+
+type treeEntry struct {
+	mode uint32
+	hash Hash
+}
+
+func (r *Repository) flattenTree(tree Hash, prefix string, files map[string]treeEntry) error {
+	typ, data, err := r.objects.read(tree)
+	if err != nil {
+		return err
+	}
+	if typ != objTree {
+		return fmt.Errorf("object %s is not a tree", tree)
+	}
+	for len(data) > 0 {
+		sp := bytes.IndexByte(data, ' ')
+		nul := bytes.IndexByte(data, 0)
+		if sp < 0 || nul < sp || len(data) < nul+1+hashSize {
+			return fmt.Errorf("invalid tree %s", tree)
+		}
+		mode, name, end := string(data[:sp]), string(data[sp+1:nul]), nul+1
+		var e treeEntry
+		copy(e.hash[:], data[end:end+hashSize])
+		data = data[end+hashSize:]
+		if _, err := fmt.Sscanf(mode, "%o", &e.mode); err != nil {
+			return fmt.Errorf("invalid tree %s: %w", tree, err)
+		}
+		if e.mode == modeTree {
+			if err := r.flattenTree(e.hash, prefix+name+"/", files); err != nil {
+				return err
+			}
+			continue
+		}
+		files[prefix+name] = e
+	}
+	return nil
+}
+
+func resolve(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
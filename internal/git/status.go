@@ -0,0 +1,315 @@
+package git
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sochoa/go-ls/internal/ignore"
+	"github.com/sochoa/go-ls/internal/stat"
+)
+
+// Status codes, as in the short format of git status.
+const (
+	Unmodified = ' '
+	Modified   = 'M'
+	Added      = 'A'
+	Deleted    = 'D'
+	Unmerged   = 'U'
+	Untracked  = '?'
+	Ignored    = '!'
+)
+
+// Status is the state of a work tree: for each tracked file, how the index
+// differs from HEAD and how the work tree differs from the index. The work
+// tree side is worked out for a file only when its status is asked for,
+// so a Status is not safe for concurrent use.
+type Status struct {
+	root       string
+	indexMTime time.Time
+	files      map[string]*trackedFile
+	paths      []string // the keys of files, sorted
+	ignored    *ignore.Matcher
+}
+
+// trackedFile is a file in the index. worktree is 0 until it is known.
+type trackedFile struct {
+	entry    IndexEntry
+	staged   byte
+	worktree byte
+}
+
+// Status compares the index with HEAD. The work tree is compared with the
+// index later, a file at a time, as Of is called.
+func (r *Repository) Status() (*Status, error) {
+	indexPath := filepath.Join(r.GitDir, "index")
+	data, err := os.ReadFile(indexPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	var entries []IndexEntry
+	ignored := ignore.New()
+	ignored.GitOnly = true
+	s := &Status{root: r.WorkTree, files: map[string]*trackedFile{}, ignored: ignored}
+	if err == nil {
+		if entries, err = ReadIndex(data); err != nil {
+			return nil, err
+		}
+		if fi, err := os.Stat(indexPath); err == nil {
+			s.indexMTime = fi.ModTime()
+		}
+	}
+	head, err := r.headTree()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		f := &trackedFile{entry: e, staged: Unmodified}
+		h, inHead := head[e.Path]
+		switch {
+		case e.Stage > 0:
+			f.staged, f.worktree = Unmerged, Unmerged
+		case e.IntentToAdd:
+			f.worktree = Added
+		case !inHead:
+			f.staged = Added
+		case h.hash != e.Hash || h.mode != e.Mode:
+			f.staged = Modified
+		}
+		if (e.SkipWorktree || e.Mode == modeGitlink) && f.worktree == 0 {
+			f.worktree = Unmodified
+		}
+		s.files[e.Path] = f
+	}
+	for path := range s.files {
+		s.paths = append(s.paths, path)
+	}
+	sort.Strings(s.paths)
+	return s, nil
+}
+
+// code returns the two-letter status of f, comparing the work tree copy
+// with the index first if that has not been done. e is the entry listed
+// for the file, or nil to lstat it.
+func (s *Status) code(f *trackedFile, e *stat.Stat) string {
+	if f.worktree == 0 {
+		path := filepath.Join(s.root, filepath.FromSlash(f.entry.Path))
+		var (
+			wf  worktreeFile
+			err error
+		)
+		if e != nil {
+			wf = entryFile(*e)
+		} else {
+			wf, err = lstatFile(path)
+		}
+		if err != nil {
+			f.worktree = Deleted
+		} else {
+			f.worktree = worktreeStatus(f.entry, wf, path, s.indexMTime)
+		}
+	}
+	return string([]byte{f.staged, f.worktree})
+}
+
+// worktreeFile is what the index records of a file in the work tree.
+type worktreeFile struct {
+	mode  uint32
+	size  int64
+	mtime time.Time
+	inode uint64
+}
+
+// entryFile takes a worktreeFile from an entry that has already been
+// stat-ed.
+func entryFile(e stat.Stat) worktreeFile {
+	mode := uint32(modeRegular)
+	switch {
+	case e.Type == stat.SymbolicLinkFileType:
+		mode = modeSymlink
+	case e.Type == stat.DirectoryFileType:
+		mode = modeGitlink
+	case e.Mode&0o111 != 0:
+		mode = modeExecutable
+	}
+	return worktreeFile{mode: mode, size: e.SizeBytes, mtime: e.LastModifiedTime.Time, inode: e.Inode}
+}
+
+// lstatFile takes a worktreeFile from the file at path, for tracked files
+// beneath a listed directory.
+func lstatFile(path string) (worktreeFile, error) {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return worktreeFile{}, err
+	}
+	wf := worktreeFile{mode: fileMode(fi), size: fi.Size(), mtime: fi.ModTime()}
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		wf.inode = uint64(st.Ino)
+	}
+	return wf, nil
+}
+
+// worktreeStatus compares the work tree copy of e at path with the index.
+// Files whose size, modification time, inode and mode match what the index
+// recorded are taken to be unchanged, as git does; only the others, and
+// files modified too close to the index write to tell, are hashed.
+func worktreeStatus(e IndexEntry, wf worktreeFile, path string, indexMTime time.Time) byte {
+	if wf.mode != e.Mode {
+		return Modified
+	}
+	if uint32(wf.size) != e.Size {
+		return Modified
+	}
+	sameTime := wf.mtime.Unix() == e.ModTime.Unix() &&
+		(e.ModTime.Nanosecond() == 0 || wf.mtime.Nanosecond() == e.ModTime.Nanosecond())
+	sameInode := e.Inode == 0 || uint32(wf.inode) == e.Inode
+	racy := !wf.mtime.Before(indexMTime)
+	if sameTime && sameInode && !racy {
+		return Unmodified
+	}
+
+	var (
+		content []byte
+		err     error
+	)
+	if e.Mode == modeSymlink {
+		target, err := os.Readlink(path)
+		content = []byte(target)
+		if err != nil {
+			return Modified
+		}
+	} else if content, err = os.ReadFile(path); err != nil {
+		return Modified
+	}
+	if blobHash(content) != e.Hash {
+		return Modified
+	}
+	return Unmodified
+}
+
+// fileMode returns the mode git would record for fi.
+func fileMode(fi fs.FileInfo) uint32 {
+	switch {
+	case fi.Mode()&fs.ModeSymlink != 0:
+		return modeSymlink
+	case fi.IsDir():
+		return modeGitlink
+	case fi.Mode()&0o111 != 0:
+		return modeExecutable
+	}
+	return modeRegular
+}
+
+// Of returns the two-letter status of the listed entry e: "  " for a clean
+// tracked file, "M " staged, " M" modified, "??" untracked, "!!" ignored
+// and so on. A directory combines the files tracked beneath it, a column
+// showing the change they share or M when they differ. Paths outside the
+// work tree, and the .git directory, have no status.
+func (s *Status) Of(e stat.Stat) string {
+	path := e.AbsolutePath
+	isDir := e.Type == stat.DirectoryFileType
+	rel, err := filepath.Rel(s.root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == ".git" || strings.HasPrefix(rel, ".git/") {
+		return ""
+	}
+	if !isDir {
+		if f, ok := s.files[rel]; ok {
+			return s.code(f, &e)
+		}
+	} else if status, ok := s.dirStatus(rel); ok {
+		return status
+	}
+	if s.ignored.Ignored(path, isDir) {
+		return string([]byte{Ignored, Ignored})
+	}
+	return string([]byte{Untracked, Untracked})
+}
+
+func (s *Status) dirStatus(rel string) (string, bool) {
+	prefix := rel + "/"
+	if rel == "." {
+		prefix = ""
+	}
+	i := sort.SearchStrings(s.paths, prefix)
+	if i == len(s.paths) || !strings.HasPrefix(s.paths[i], prefix) {
+		return "", false
+	}
+	combined := []byte{Unmodified, Unmodified}
+	for ; i < len(s.paths) && strings.HasPrefix(s.paths[i], prefix); i++ {
+		status := s.code(s.files[s.paths[i]], nil)
+		for col := range combined {
+			switch {
+			case status[col] == Unmodified || status[col] == combined[col]:
+			case combined[col] == Unmodified:
+				combined[col] = status[col]
+			default:
+				combined[col] = Modified
+			}
+		}
+	}
+	return string(combined), true
+}
+
+// Cache finds the repository of each path and computes its status once.
+type Cache struct {
+	mu    sync.Mutex
+	dirs  map[string]*cachedRepo
+	repos map[string]*cachedRepo
+}
+
+type cachedRepo struct {
+	status *Status
+	err    error
+}
+
+func NewCache() *Cache {
+	return &Cache{dirs: map[string]*cachedRepo{}, repos: map[string]*cachedRepo{}}
+}
+
+// Status returns the status of the listed entry e, or "" outside a work
+// tree. An error reading a repository is returned for the first path in
+// it only, so that it is reported once.
+func (c *Cache) Status(e stat.Stat) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dir := filepath.Dir(e.AbsolutePath)
+	repo, ok := c.dirs[dir]
+	if !ok {
+		var err error
+		repo, err = c.open(dir)
+		if err != nil {
+			c.dirs[dir] = &cachedRepo{}
+			return "", err
+		}
+		c.dirs[dir] = repo
+	}
+	if repo == nil || repo.status == nil {
+		return "", nil
+	}
+	return repo.status.Of(e), nil
+}
+
+func (c *Cache) open(dir string) (*cachedRepo, error) {
+	r, err := Discover(dir)
+	if err != nil || r == nil {
+		return nil, err
+	}
+	if repo, ok := c.repos[r.WorkTree]; ok {
+		return repo, nil
+	}
+	repo := &cachedRepo{}
+	repo.status, repo.err = r.Status()
+	c.repos[r.WorkTree] = repo
+	return repo, repo.err
+}
@@ -0,0 +1,308 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sochoa/go-ls/internal/stat"
+	"github.com/stretchr/testify/require"
+)
+
+// fixture builds a repository in a temporary directory the way git would
+// lay it out, without running git.
+type fixture struct {
+	t      *testing.T
+	root   string
+	gitDir string
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	root := t.TempDir()
+	f := &fixture{t: t, root: root, gitDir: filepath.Join(root, ".git")}
+	for _, dir := range []string{"objects", "refs/heads", "info"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(f.gitDir, dir), 0o755))
+	}
+	f.writeGit("HEAD", "ref: refs/heads/main\n")
+	return f
+}
+
+func (f *fixture) writeGit(name, content string) {
+	f.t.Helper()
+	require.NoError(f.t, os.WriteFile(filepath.Join(f.gitDir, name), []byte(content), 0o644))
+}
+
+func (f *fixture) write(path, content string) {
+	f.t.Helper()
+	full := filepath.Join(f.root, path)
+	require.NoError(f.t, os.MkdirAll(filepath.Dir(full), 0o755))
+	require.NoError(f.t, os.WriteFile(full, []byte(content), 0o644))
+}
+
+func (f *fixture) object(typ string, content []byte) Hash {
+	f.t.Helper()
+	h := objectHash(typ, content)
+	dir := filepath.Join(f.gitDir, "objects", h.String()[:2])
+	require.NoError(f.t, os.MkdirAll(dir, 0o755))
+	data := deflate(append([]byte(fmt.Sprintf("%s %d\x00", typ, len(content))), content...))
+	require.NoError(f.t, os.WriteFile(filepath.Join(dir, h.String()[2:]), data, 0o444))
+	return h
+}
+
+// commit stores the work tree copies of paths as the tree of a commit on
+// main.
+func (f *fixture) commit(paths ...string) Hash {
+	f.t.Helper()
+	blobs := map[string]Hash{}
+	for _, path := range paths {
+		content, err := os.ReadFile(filepath.Join(f.root, path))
+		require.NoError(f.t, err)
+		blobs[path] = f.object("blob", content)
+	}
+	tree := f.tree(blobs, "")
+	commit := f.object("commit", []byte(fmt.Sprintf("tree %s\nauthor a <a> 0 +0000\n\nfixture\n", tree)))
+	f.writeGit("refs/heads/main", commit.String()+"\n")
+	return commit
+}
+
+func (f *fixture) tree(blobs map[string]Hash, prefix string) Hash {
+	entries := map[string]string{}
+	for path, h := range blobs {
+		rest, ok := strings.CutPrefix(path, prefix)
+		if !ok {
+			continue
+		}
+		if dir, _, nested := strings.Cut(rest, "/"); nested {
+			entries[dir] = "40000 " + dir + "\x00" + string(f.tree(blobs, prefix+dir+"/").bytes())
+		} else {
+			entries[rest] = "100644 " + rest + "\x00" + string(h.bytes())
+		}
+	}
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var content strings.Builder
+	for _, name := range names {
+		content.WriteString(entries[name])
+	}
+	return f.object("tree", []byte(content.String()))
+}
+
+func (h Hash) bytes() []byte { return h[:] }
+
+// stage writes an index recording the current work tree copies of paths.
+func (f *fixture) stage(version uint32, paths ...string) {
+	f.t.Helper()
+	var entries []IndexEntry
+	sort.Strings(paths)
+	for _, path := range paths {
+		full := filepath.Join(f.root, path)
+		fi, err := os.Lstat(full)
+		require.NoError(f.t, err)
+		content, err := os.ReadFile(full)
+		require.NoError(f.t, err)
+		e := IndexEntry{
+			Path:    path,
+			Mode:    fileMode(fi),
+			Hash:    blobHash(content),
+			Size:    uint32(fi.Size()),
+			ModTime: fi.ModTime(),
+		}
+		if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+			e.Inode = uint32(st.Ino)
+		}
+		entries = append(entries, e)
+	}
+	f.writeGit("index", string(encodeIndex(version, entries)))
+	// Move the index past the files so that they are not racily clean.
+	later := time.Now().Add(time.Minute)
+	require.NoError(f.t, os.Chtimes(filepath.Join(f.gitDir, "index"), later, later))
+}
+
+// entry stats path beneath the work tree as the walker would list it.
+func (f *fixture) entry(path string) stat.Stat {
+	f.t.Helper()
+	return lstatEntry(f.t, filepath.Join(f.root, path))
+}
+
+func lstatEntry(t *testing.T, path string) stat.Stat {
+	t.Helper()
+	var st syscall.Stat_t
+	require.NoError(t, syscall.Lstat(path, &st))
+	return stat.New(path, &st)
+}
+
+func (f *fixture) status() *Status {
+	f.t.Helper()
+	r, err := Discover(filepath.Join(f.root, "src"))
+	require.NoError(f.t, err)
+	require.NotNil(f.t, r)
+	s, err := r.Status()
+	require.NoError(f.t, err)
+	return s
+}
+
+func TestStatus(t *testing.T) {
+	for _, version := range []uint32{2, 3, 4} {
+		f := newFixture(t)
+		f.write("README.md", "hello\n")
+		f.write("src/main.go", "package main\n")
+		f.write("src/util.go", "package main // util\n")
+		f.write("src/gone.go", "package main\n")
+		f.write("docs/guide.md", "# guide\n")
+		f.commit("README.md", "src/main.go", "src/util.go", "src/gone.go", "docs/guide.md")
+
+		f.write("src/new.go", "package main // new\n")
+		f.write("docs/guide.md", "# staged guide\n")
+		f.stage(version, "README.md", "src/main.go", "src/util.go", "src/gone.go", "src/new.go", "docs/guide.md")
+
+		f.write("README.md", "hello, world\n")
+		// Same size, so only the content hash shows the change.
+		f.write("src/util.go", "package main // UTIL\n")
+		require.NoError(t, os.Remove(filepath.Join(f.root, "src/gone.go")))
+		f.write("notes.txt", "untracked\n")
+		f.write(".gitignore", "*.log\nbuild/\n")
+		f.write("debug.log", "ignored\n")
+		f.write("build/out.bin", "ignored\n")
+
+		s := f.status()
+		tests := []struct {
+			path string
+			want string
+		}{
+			{"README.md", " M"},
+			{"src/main.go", "  "},
+			{"src/util.go", " M"},
+			{"src/new.go", "A "},
+			{"docs/guide.md", "M "},
+			{"notes.txt", "??"},
+			{".gitignore", "??"},
+			{"debug.log", "!!"},
+			{"build", "!!"},
+			{"build/out.bin", "!!"},
+			{"src", "AM"},
+			{"docs", "M "},
+			{".", "MM"},
+			{".git", ""},
+			{"..", ""},
+		}
+		for _, tt := range tests {
+			require.Equal(t, tt.want, s.Of(f.entry(tt.path)), "index v%d: %s", version, tt.path)
+		}
+		// A deleted file cannot be listed, but shows in its directory.
+		require.Equal(t, " D", s.code(s.files["src/gone.go"], nil), "index v%d", version)
+	}
+}
+
+func TestStatusComparesListedEntries(t *testing.T) {
+	f := newFixture(t)
+	f.write("a.go", "package a\n")
+	f.write("b.go", "package b\n")
+	f.commit("a.go", "b.go")
+	f.stage(2, "a.go", "b.go")
+	r, err := Discover(f.root)
+	require.NoError(t, err)
+	s, err := r.Status()
+	require.NoError(t, err)
+
+	// Rewrite a.go keeping its size and modification time. The listed
+	// entry matches the index, so the content is not hashed.
+	a := f.entry("a.go")
+	f.write("a.go", "package A\n")
+	require.NoError(t, os.Chtimes(filepath.Join(f.root, "a.go"), a.LastModifiedTime.Time, a.LastModifiedTime.Time))
+	require.Equal(t, "  ", s.Of(a))
+
+	// A stat that differs from the index is checked against the content.
+	a.LastModifiedTime.Time = a.LastModifiedTime.Add(time.Second)
+	s, err = r.Status()
+	require.NoError(t, err)
+	require.Equal(t, " M", s.Of(a))
+
+	// Nothing was compared for b.go, which was never listed.
+	require.Zero(t, s.files["b.go"].worktree)
+}
+
+func TestStatusUnbornAndPacked(t *testing.T) {
+	f := newFixture(t)
+	f.write("src/a.go", "package a\n")
+	f.stage(2, "src/a.go")
+	require.Equal(t, "A ", f.status().Of(f.entry("src/a.go")))
+
+	// A branch in packed-refs, with HEAD detached onto a commit in a pack.
+	f = newFixture(t)
+	f.write("src/a.go", "package a\n")
+	f.stage(2, "src/a.go")
+	blob := objectHash("blob", []byte("package a\n"))
+	sub := "100644 a.go\x00" + string(blob.bytes())
+	subTree := objectHash("tree", []byte(sub))
+	root := "40000 src\x00" + string(subTree.bytes())
+	rootTree := objectHash("tree", []byte(root))
+	hashes := writePack(t, filepath.Join(f.gitDir, "objects"), []packObject{
+		{typ: objBlob, content: []byte("package a\n")},
+		{typ: objTree, content: []byte(sub)},
+		{typ: objTree, content: []byte(root)},
+		{typ: objCommit, content: []byte("tree " + rootTree.String() + "\n\npacked\n")},
+	})
+	f.writeGit("packed-refs", "# pack-refs with: peeled\n"+hashes[3].String()+" refs/heads/main\n")
+	require.Equal(t, "  ", f.status().Of(f.entry("src/a.go")))
+
+	f.writeGit("HEAD", hashes[3].String()+"\n")
+	require.Equal(t, "  ", f.status().Of(f.entry("src/a.go")))
+}
+
+func TestLinkedWorkTree(t *testing.T) {
+	f := newFixture(t)
+	f.write("src/a.go", "package a\n")
+	f.commit("src/a.go")
+
+	wt := t.TempDir()
+	wtGit := filepath.Join(f.gitDir, "worktrees", "wt")
+	require.NoError(t, os.MkdirAll(wtGit, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(wtGit, "commondir"), []byte("../..\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(wtGit, "HEAD"), []byte("ref: refs/heads/main\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(wt, ".git"), []byte("gitdir: "+wtGit+"\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(wt, "a.go"), nil, 0o644))
+
+	r, err := Discover(wt)
+	require.NoError(t, err)
+	require.Equal(t, f.gitDir, r.CommonDir)
+	s, err := r.Status()
+	require.NoError(t, err)
+	require.Equal(t, "??", s.Of(lstatEntry(t, filepath.Join(wt, "a.go"))))
+}
+
+func TestCache(t *testing.T) {
+	f := newFixture(t)
+	f.write("a.go", "package a\n")
+	f.stage(2, "a.go")
+	outside := t.TempDir()
+
+	file := func(path string) stat.Stat {
+		return stat.Stat{AbsolutePath: path, Type: stat.RegularFileType}
+	}
+
+	c := NewCache()
+	status, err := c.Status(f.entry("a.go"))
+	require.NoError(t, err)
+	require.Equal(t, "A ", status)
+	status, err = c.Status(file(filepath.Join(outside, "x")))
+	require.NoError(t, err)
+	require.Equal(t, "", status)
+
+	broken := newFixture(t)
+	broken.writeGit("index", "garbage")
+	c = NewCache()
+	_, err = c.Status(file(filepath.Join(broken.root, "a")))
+	require.ErrorContains(t, err, "not a git index")
+	status, err = c.Status(file(filepath.Join(broken.root, "b")))
+	require.NoError(t, err)
+	require.Equal(t, "", status)
+}
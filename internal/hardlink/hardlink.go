@@ -0,0 +1,72 @@
+// Package hardlink groups entries that share an inode, to show which paths
+// are hard links to the same file and how many of its links lie elsewhere.
+package hardlink
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/sochoa/go-ls/internal/stat"
+)
+
+// Group is one inode with more than one link and the paths found for it.
+type Group struct {
+	Device stat.DeviceID `json:"device"`
+	Inode  uint64        `json:"inode"`
+	// Links is the link count of the inode.
+	Links uint64 `json:"links"`
+	// Paths are the absolute paths found for the inode, sorted.
+	Paths []string `json:"paths"`
+	// Missing is how many links were not found among the entries seen.
+	Missing uint64 `json:"missing"`
+}
+
+type key struct {
+	device stat.DeviceID
+	inode  uint64
+}
+
+// Collector gathers entries into groups by device and inode. The zero
+// value is ready to use.
+type Collector struct {
+	groups map[key]*Group
+}
+
+// Add records m when it is not a directory and has more than one link.
+// Directories are skipped because their link count reflects their
+// subdirectories, not hard links. A path seen twice is counted once.
+func (c *Collector) Add(m stat.CommonStat) {
+	s := m.GetStat()
+	if s.Type == stat.DirectoryFileType || s.HardLinkReferenceCount < 2 {
+		return
+	}
+	if c.groups == nil {
+		c.groups = map[key]*Group{}
+	}
+	k := key{s.Device, s.Inode}
+	g, ok := c.groups[k]
+	if !ok {
+		g = &Group{Device: s.Device, Inode: s.Inode}
+		c.groups[k] = g
+	}
+	// The link count may change while the tree is read; keep the latest.
+	g.Links = s.HardLinkReferenceCount
+	if !slices.Contains(g.Paths, s.AbsolutePath) {
+		g.Paths = append(g.Paths, s.AbsolutePath)
+	}
+}
+
+// Groups returns every group with its paths sorted, ordered by first path.
+func (c *Collector) Groups() []Group {
+	groups := make([]Group, 0, len(c.groups))
+	for _, g := range c.groups {
+		group := *g
+		group.Paths = slices.Sorted(slices.Values(g.Paths))
+		group.Missing = group.Links - min(group.Links, uint64(len(group.Paths)))
+		groups = append(groups, group)
+	}
+	slices.SortFunc(groups, func(a, b Group) int {
+		return cmp.Compare(a.Paths[0], b.Paths[0])
+	})
+	return groups
+}
@@ -0,0 +1,74 @@
+package hardlink
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sochoa/go-ls/internal/stat"
+	"github.com/sochoa/go-ls/internal/walk"
+	"github.com/stretchr/testify/require"
+)
+
+// collect walks root and gathers every entry.
+func collect(t *testing.T, roots ...string) []Group {
+	t.Helper()
+	var c Collector
+	for _, root := range roots {
+		err := walk.Walk(root, func(path string, m stat.CommonStat, err error) error {
+			require.NoError(t, err)
+			c.Add(m)
+			return nil
+		})
+		require.NoError(t, err)
+	}
+	return c.Groups()
+}
+
+func writeFile(t *testing.T, path string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(path), 0o644))
+}
+
+func TestGroups(t *testing.T) {
+	root := t.TempDir()
+	path := func(name string) string { return filepath.Join(root, name) }
+
+	writeFile(t, path("backup/a"))
+	require.NoError(t, os.MkdirAll(path("backup/old"), 0o755))
+	require.NoError(t, os.MkdirAll(path("outside"), 0o755))
+	require.NoError(t, os.Link(path("backup/a"), path("backup/old/a")))
+	require.NoError(t, os.Link(path("backup/a"), path("outside/a")))
+	writeFile(t, path("backup/b"))
+	require.NoError(t, os.Link(path("backup/b"), path("backup/c")))
+	writeFile(t, path("backup/single"))
+	require.NoError(t, os.Symlink("a", path("backup/link")))
+
+	groups := collect(t, path("backup"))
+	require.Len(t, groups, 2)
+
+	require.Equal(t, []string{path("backup/a"), path("backup/old/a")}, groups[0].Paths)
+	require.Equal(t, uint64(3), groups[0].Links)
+	require.Equal(t, uint64(1), groups[0].Missing)
+	require.Equal(t, []string{path("backup/b"), path("backup/c")}, groups[1].Paths)
+	require.Equal(t, uint64(2), groups[1].Links)
+	require.Equal(t, uint64(0), groups[1].Missing)
+	require.NotEqual(t, groups[0].Inode, groups[1].Inode)
+	require.Equal(t, groups[0].Device, groups[1].Device)
+
+	// Overlapping roots count each path once, and the whole tree accounts
+	// for every link.
+	groups = collect(t, root, path("backup"))
+	require.Len(t, groups, 2)
+	require.Equal(t, []string{path("backup/a"), path("backup/old/a"), path("outside/a")}, groups[0].Paths)
+	require.Equal(t, uint64(0), groups[0].Missing)
+}
+
+func TestGroupsSkipDirectories(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "dir", "sub"), 0o755))
+	writeFile(t, filepath.Join(root, "dir", "sub", "file"))
+
+	require.Empty(t, collect(t, root))
+}
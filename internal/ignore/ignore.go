@@ -0,0 +1,255 @@
+// Package ignore decides which paths version control ignores, following
+// gitignore semantics for .gitignore files, .git/info/exclude, the global
+// excludes file and .ignore files.
+package ignore
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Deps are the filesystem and environment lookups of a Matcher.
+type Deps struct {
+	ReadFile    func(name string) ([]byte, error)
+	Stat        func(name string) (fs.FileInfo, error)
+	Getenv      func(key string) string
+	UserHomeDir func() (string, error)
+}
+
+// Matcher reports ignored paths. Ignore files are read once per directory
+// and the matcher is safe for concurrent use.
+type Matcher struct {
+	// GitOnly skips .ignore files, matching what git itself ignores.
+	GitOnly bool
+
+	deps Deps
+
+	mu       sync.Mutex
+	repos    map[string]*repo
+	patterns map[string][]pattern
+	dirs     map[string]bool
+}
+
+// repo is a git work tree.
+type repo struct {
+	root string
+	// excludes are the patterns of the global excludes file and
+	// .git/info/exclude, which apply to the whole work tree.
+	excludes []pattern
+}
+
+func New() *Matcher {
+	return NewWithDeps(Deps{
+		ReadFile:    os.ReadFile,
+		Stat:        os.Stat,
+		Getenv:      os.Getenv,
+		UserHomeDir: os.UserHomeDir,
+	})
+}
+
+func NewWithDeps(deps Deps) *Matcher {
+	return &Matcher{
+		deps:     deps,
+		repos:    map[string]*repo{},
+		patterns: map[string][]pattern{},
+		dirs:     map[string]bool{},
+	}
+}
+
+// Ignored reports whether the absolute path is ignored, either itself or
+// because a directory above it is. Within a git work tree .gitignore files,
+// .git/info/exclude and the global excludes file apply, as does the .git
+// directory itself; .ignore files apply everywhere and take precedence.
+// As in git, the last matching pattern wins and files in deeper
+// directories override those above them.
+func (m *Matcher) Ignored(path string, isDir bool) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	path = filepath.Clean(path)
+	return m.dirIgnored(filepath.Dir(path)) || m.decide(path, isDir)
+}
+
+// dirIgnored reports whether the directory dir or one above it, up to the
+// top of its work tree, is ignored.
+func (m *Matcher) dirIgnored(dir string) bool {
+	if ignored, ok := m.dirs[dir]; ok {
+		return ignored
+	}
+	ignored := false
+	if r := m.repoFor(dir); !isTop(dir, r) {
+		ignored = m.dirIgnored(filepath.Dir(dir)) || m.decide(dir, true)
+	}
+	m.dirs[dir] = ignored
+	return ignored
+}
+
+// decide applies the patterns above path, without looking at its parents.
+func (m *Matcher) decide(path string, isDir bool) bool {
+	parent := filepath.Dir(path)
+	r := m.repoFor(parent)
+	if r != nil && filepath.Base(path) == ".git" {
+		return true
+	}
+	var lists [][]pattern
+	if r != nil {
+		lists = append(lists, r.excludes)
+	}
+	var dirs []string
+	for dir := parent; ; dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+		if isTop(dir, r) {
+			break
+		}
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		lists = append(lists, m.dirPatterns(dirs[i], r != nil))
+	}
+
+	ignored := false
+	for _, list := range lists {
+		for _, p := range list {
+			if p.match(path, isDir) {
+				ignored = !p.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// isTop reports whether dir is the root of the work tree r, or of the
+// filesystem outside one.
+func isTop(dir string, r *repo) bool {
+	if r != nil {
+		return dir == r.root
+	}
+	return dir == filepath.Dir(dir)
+}
+
+// dirPatterns returns the patterns of the ignore files in dir.
+func (m *Matcher) dirPatterns(dir string, inRepo bool) []pattern {
+	if patterns, ok := m.patterns[dir]; ok {
+		return patterns
+	}
+	var patterns []pattern
+	var names []string
+	if inRepo {
+		names = append(names, ".gitignore")
+	}
+	if !m.GitOnly {
+		names = append(names, ".ignore")
+	}
+	for _, name := range names {
+		if data, err := m.deps.ReadFile(filepath.Join(dir, name)); err == nil {
+			patterns = append(patterns, parsePatterns(data, dir)...)
+		}
+	}
+	m.patterns[dir] = patterns
+	return patterns
+}
+
+// repoFor returns the git work tree containing dir, or nil.
+func (m *Matcher) repoFor(dir string) *repo {
+	if r, ok := m.repos[dir]; ok {
+		return r
+	}
+	var r *repo
+	if fi, err := m.deps.Stat(filepath.Join(dir, ".git")); err == nil {
+		r = m.openRepo(dir, fi.IsDir())
+	} else if parent := filepath.Dir(dir); parent != dir {
+		r = m.repoFor(parent)
+	}
+	m.repos[dir] = r
+	return r
+}
+
+func (m *Matcher) openRepo(root string, gitIsDir bool) *repo {
+	gitDir := filepath.Join(root, ".git")
+	if !gitIsDir {
+		// Linked work trees and submodules have a .git file pointing at
+		// the repository.
+		data, err := m.deps.ReadFile(gitDir)
+		if err != nil {
+			return nil
+		}
+		target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+		if !ok {
+			return nil
+		}
+		gitDir = resolve(root, strings.TrimSpace(target))
+	}
+	commonDir := gitDir
+	if data, err := m.deps.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir = resolve(gitDir, strings.TrimSpace(string(data)))
+	}
+
+	r := &repo{root: root}
+	if data, err := m.deps.ReadFile(m.excludesFile(commonDir)); err == nil {
+		r.excludes = parsePatterns(data, root)
+	}
+	if data, err := m.deps.ReadFile(filepath.Join(commonDir, "info", "exclude")); err == nil {
+		r.excludes = append(r.excludes, parsePatterns(data, root)...)
+	}
+	return r
+}
+
+// excludesFile returns the core.excludesFile of the user's or the
+// repository's git config, or git's default of $XDG_CONFIG_HOME/git/ignore.
+func (m *Matcher) excludesFile(commonDir string) string {
+	home, _ := m.deps.UserHomeDir()
+	configHome := m.deps.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+	file := filepath.Join(configHome, "git", "ignore")
+	for _, config := range []string{
+		filepath.Join(configHome, "git", "config"),
+		filepath.Join(home, ".gitconfig"),
+		filepath.Join(commonDir, "config"),
+	} {
+		data, err := m.deps.ReadFile(config)
+		if err != nil {
+			continue
+		}
+		if v, ok := configValue(data, "core", "excludesfile"); ok {
+			file = v
+			if rest, ok := strings.CutPrefix(v, "~/"); ok {
+				file = filepath.Join(home, rest)
+			}
+		}
+	}
+	return file
+}
+
+// configValue returns the last value of section.key in a git config file.
+// Section and key names are case insensitive; subsections are skipped.
+func configValue(data []byte, section, key string) (string, bool) {
+	var (
+		current string
+		value   string
+		found   bool
+	)
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';':
+		case line[0] == '[':
+			current = strings.ToLower(strings.Trim(line, "[] \t"))
+		case current == section:
+			k, v, _ := strings.Cut(line, "=")
+			if strings.EqualFold(strings.TrimSpace(k), key) {
+				value, found = strings.Trim(strings.TrimSpace(v), `"`), true
+			}
+		}
+	}
+	return value, found
+}
+
+func resolve(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
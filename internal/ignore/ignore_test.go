@@ -0,0 +1,130 @@
+package ignore
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testMatcher(files fstest.MapFS) *Matcher {
+	rel := func(name string) string { return strings.TrimPrefix(name, "/") }
+	return NewWithDeps(Deps{
+		ReadFile: func(name string) ([]byte, error) { return files.ReadFile(rel(name)) },
+		Stat:     func(name string) (fs.FileInfo, error) { return fs.Stat(files, rel(name)) },
+		Getenv:   func(string) string { return "" },
+		UserHomeDir: func() (string, error) {
+			return "/home/alice", nil
+		},
+	})
+}
+
+func file(s string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(s)} }
+
+func TestIgnored(t *testing.T) {
+	m := testMatcher(fstest.MapFS{
+		"home/alice/.gitconfig":      file("[user]\n\tname = alice\n[core]\n\texcludesFile = ~/.excludes\n"),
+		"home/alice/.excludes":       file("*.swp\n"),
+		"src/repo/.git/HEAD":         file("ref: refs/heads/main\n"),
+		"src/repo/.git/info/exclude": file("/scratch\n"),
+		"src/repo/.gitignore": file(strings.Join([]string{
+			"# build output",
+			"node_modules/",
+			"*.log",
+			"!keep.log",
+			"/build",
+			"docs/**/*.pdf",
+			"**/generated",
+			"tmp/**",
+			`\#notes`,
+			"trailing   ",
+		}, "\n")),
+		"src/repo/pkg/.gitignore": file("!debug.log\n*.out\n"),
+		"src/repo/pkg/.ignore":    file("*.out\n!wanted.out\nfixtures\n"),
+		"src/plain/.gitignore":    file("*\n"),
+		"src/plain/.ignore":       file("[a-c]?.txt\n"),
+	})
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"/src/repo/main.go", false, false},
+		{"/src/repo/.git", true, true},
+		{"/src/repo/.gitignore", false, false},
+		{"/src/repo/node_modules", true, true},
+		{"/src/repo/node_modules", false, false},
+		{"/src/repo/web/node_modules/react/index.js", false, true},
+		{"/src/repo/server.log", false, true},
+		{"/src/repo/keep.log", false, false},
+		{"/src/repo/pkg/debug.log", false, false},
+		{"/src/repo/pkg/other.log", false, true},
+		{"/src/repo/build", true, true},
+		{"/src/repo/pkg/build", true, false},
+		{"/src/repo/docs/a/b/guide.pdf", false, true},
+		{"/src/repo/docs/guide.pdf", false, true},
+		{"/src/repo/pdf/guide.pdf", false, false},
+		{"/src/repo/a/b/generated", true, true},
+		{"/src/repo/tmp/x/y", false, true},
+		{"/src/repo/tmp", true, false},
+		{"/src/repo/#notes", false, true},
+		{"/src/repo/trailing", false, true},
+		{"/src/repo/scratch", false, true},
+		{"/src/repo/pkg/scratch", false, false},
+		{"/src/repo/.main.go.swp", false, true},
+		{"/src/repo/pkg/a.out", false, true},
+		{"/src/repo/pkg/wanted.out", false, false},
+		{"/src/repo/pkg/fixtures/x", false, true},
+		// Outside a work tree only .ignore files apply.
+		{"/src/plain/main.go", false, false},
+		{"/src/plain/b1.txt", false, true},
+		{"/src/plain/d1.txt", false, false},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, m.Ignored(tt.path, tt.isDir), tt.path)
+	}
+}
+
+func TestLinkedWorkTree(t *testing.T) {
+	m := testMatcher(fstest.MapFS{
+		"src/main/.git/info/exclude":           file("*.tmp\n"),
+		"src/main/.git/worktrees/wt/commondir": file("../..\n"),
+		"src/wt/.git":                          file("gitdir: /src/main/.git/worktrees/wt\n"),
+		"src/wt/.gitignore":                    file("bin/\n"),
+	})
+	require.True(t, m.Ignored("/src/wt/a.tmp", false))
+	require.True(t, m.Ignored("/src/wt/bin", true))
+	require.False(t, m.Ignored("/src/wt/main.go", false))
+}
+
+func TestGlobRegexp(t *testing.T) {
+	tests := []struct{ glob, want string }{
+		{"*.go", `[^/]*\.go`},
+		{"a?c", `a[^/]c`},
+		{"**/x", `(?:.*/)?x`},
+		{"a/**", `a/.*`},
+		{"a/**/b", `a/(?:.*/)?b`},
+		{"a**b", `a[^/]*b`},
+		{"[!a-c]", `[^a-c]`},
+		{"[]x]", `[\]x]`},
+		{"[oops", `\[oops`},
+		{`\*`, `\*`},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, globRegexp(tt.glob), tt.glob)
+	}
+}
+
+func TestUnreadableIgnoreFiles(t *testing.T) {
+	m := NewWithDeps(Deps{
+		ReadFile:    func(string) ([]byte, error) { return nil, errors.New("permission denied") },
+		Stat:        func(string) (fs.FileInfo, error) { return nil, fs.ErrNotExist },
+		Getenv:      func(string) string { return "" },
+		UserHomeDir: func() (string, error) { return "", errors.New("no home") },
+	})
+	require.False(t, m.Ignored("/src/x", false))
+}
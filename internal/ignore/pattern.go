@@ -0,0 +1,173 @@
+package ignore
+
+import (
+	"regexp"
+	"strings"
+)
+
+// pattern is one line of a gitignore file.
+type pattern struct {
+	re      *regexp.Regexp
+	base    string // the directory the pattern is relative to
+	negate  bool
+	dirOnly bool
+	// anchored patterns contain a slash and match the path relative to
+	// base; the others match the name at any depth.
+	anchored bool
+}
+
+// parsePatterns reads the patterns of a gitignore file in base, skipping
+// blank lines, comments and lines that do not compile.
+func parsePatterns(data []byte, base string) []pattern {
+	var patterns []pattern
+	for _, line := range strings.Split(string(data), "\n") {
+		if p, ok := parsePattern(line, base); ok {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+func parsePattern(line, base string) (pattern, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if line == "" || line[0] == '#' {
+		return pattern{}, false
+	}
+	p := pattern{base: base}
+	if line[0] == '!' {
+		p.negate, line = true, line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly, line = true, strings.TrimSuffix(line, "/")
+	}
+	if line == "" {
+		return pattern{}, false
+	}
+	p.anchored = strings.Contains(line, "/")
+	re, err := regexp.Compile("^" + globRegexp(strings.TrimPrefix(line, "/")) + "$")
+	if err != nil {
+		return pattern{}, false
+	}
+	p.re = re
+	return p, true
+}
+
+// match reports whether the pattern applies to path, which lies beneath
+// the pattern's base.
+func (p pattern) match(path string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	rel, ok := relative(p.base, path)
+	if !ok {
+		return false
+	}
+	if !p.anchored {
+		rel = rel[strings.LastIndexByte(rel, '/')+1:]
+	}
+	return p.re.MatchString(rel)
+}
+
+// relative returns path relative to the directory base, if it lies beneath
+// it.
+func relative(base, path string) (string, bool) {
+	if base != "/" {
+		base += "/"
+	}
+	if !strings.HasPrefix(path, base) || len(path) == len(base) {
+		return "", false
+	}
+	return path[len(base):], true
+}
+
+// globRegexp translates a gitignore glob into a regular expression. * and ?
+// do not match a slash; a ** path component matches any number of them.
+func globRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			j := i
+			for j < len(glob) && glob[j] == '*' {
+				j++
+			}
+			atStart := i == 0 || glob[i-1] == '/'
+			atEnd := j == len(glob) || glob[j] == '/'
+			switch {
+			case j-i < 2 || !atStart || !atEnd:
+				// Other runs of asterisks are a plain *.
+				b.WriteString("[^/]*")
+			case j == len(glob):
+				b.WriteString(".*")
+			default:
+				b.WriteString("(?:.*/)?")
+				j++ // the slash is part of the match
+			}
+			i = j - 1
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := classEnd(glob, i)
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			b.WriteString(classRegexp(glob[i+1 : end]))
+			i = end
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+			}
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// classEnd returns the index of the ] closing the bracket expression at
+// glob[start], or -1 if it is not closed.
+func classEnd(glob string, start int) int {
+	j := start + 1
+	if j < len(glob) && (glob[j] == '!' || glob[j] == '^') {
+		j++
+	}
+	if j < len(glob) && glob[j] == ']' {
+		j++
+	}
+	for ; j < len(glob); j++ {
+		switch glob[j] {
+		case '\\':
+			j++
+		case ']':
+			return j
+		}
+	}
+	return -1
+}
+
+func classRegexp(class string) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	if class != "" && (class[0] == '!' || class[0] == '^') {
+		b.WriteByte('^')
+		class = class[1:]
+	}
+	for i := 0; i < len(class); i++ {
+		c := class[i]
+		if c == '\\' && i+1 < len(class) {
+			i++
+			c = class[i]
+		}
+		if strings.IndexByte(`\[]^`, c) >= 0 {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	b.WriteByte(']')
+	return b.String()
+}
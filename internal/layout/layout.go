@@ -0,0 +1,143 @@
+package layout
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format selects how short listings are laid out, matching the ls flags.
+type Format int
+
+const (
+	OnePerLine Format = iota // -1
+	Columns                  // -C, names sorted down the columns
+	Across                   // --across, names sorted across the rows
+	Commas                   // -m
+)
+
+// columnGap is the minimum space between two columns, as with GNU ls.
+const columnGap = 2
+
+// minColumnWidth is the width of the narrowest possible column, a one cell
+// name and its gap. It bounds how many columns can fit on a line.
+const minColumnWidth = 1 + columnGap
+
+// Write lays names out in format for a terminal lineWidth cells wide.
+// Names may carry colour escapes; only their display width is measured.
+func Write(w io.Writer, names []string, format Format, lineWidth int) error {
+	if len(names) == 0 {
+		return nil
+	}
+	switch format {
+	case Columns, Across:
+		return writeGrid(w, names, format == Across, lineWidth)
+	case Commas:
+		return writeCommas(w, names, lineWidth)
+	}
+	for _, name := range names {
+		if _, err := fmt.Fprintln(w, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeGrid(w io.Writer, names []string, across bool, lineWidth int) error {
+	widths := make([]int, len(names))
+	for i, name := range names {
+		widths[i] = Width(name)
+	}
+	rows, colWidths := fit(widths, across, lineWidth)
+	cols := len(colWidths)
+
+	var line strings.Builder
+	for row := 0; row < rows; row++ {
+		line.Reset()
+		for col := 0; col < cols; col++ {
+			idx := cellIndex(row, col, rows, cols, across)
+			if idx >= len(names) {
+				break
+			}
+			line.WriteString(names[idx])
+			next := cellIndex(row, col+1, rows, cols, across)
+			if col+1 < cols && next < len(names) {
+				line.WriteString(strings.Repeat(" ", colWidths[col]-widths[idx]))
+			}
+		}
+		if _, err := fmt.Fprintln(w, line.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fit finds the most columns that fit in lineWidth and returns the row
+// count along with the width of each column, gap included.
+func fit(widths []int, across bool, lineWidth int) (int, []int) {
+	n := len(widths)
+	// Only the last column goes without a gap, so no more than this many
+	// columns can fit whatever the names are.
+	maxCols := min(n, (lineWidth+columnGap)/minColumnWidth)
+	for cols := maxCols; cols > 1; cols-- {
+		rows := (n + cols - 1) / cols
+		if !across && (cols-1)*rows >= n {
+			// Column-major layouts with this many rows leave the last
+			// column empty; a smaller count gives the same grid.
+			continue
+		}
+		colWidths := make([]int, cols)
+		for i, wd := range widths {
+			col := i / rows
+			if across {
+				col = i % cols
+			}
+			colWidths[col] = max(colWidths[col], wd+columnGap)
+		}
+		total := 0
+		for _, wd := range colWidths {
+			total += wd
+		}
+		// The last column needs no trailing gap.
+		if total-columnGap <= lineWidth {
+			return rows, colWidths
+		}
+	}
+	return n, []int{0}
+}
+
+func cellIndex(row, col, rows, cols int, across bool) int {
+	if across {
+		return row*cols + col
+	}
+	return col*rows + row
+}
+
+func writeCommas(w io.Writer, names []string, lineWidth int) error {
+	var (
+		line strings.Builder
+		pos  int
+	)
+	for i, name := range names {
+		wd := Width(name)
+		sep := ""
+		if i < len(names)-1 {
+			sep = ","
+			wd++
+		}
+		if pos > 0 {
+			// Room for the space after the previous comma and this name.
+			if pos+1+wd > lineWidth {
+				line.WriteString("\n")
+				pos = 0
+			} else {
+				line.WriteString(" ")
+				pos++
+			}
+		}
+		line.WriteString(name + sep)
+		pos += wd
+	}
+	_, err := fmt.Fprintln(w, line.String())
+	return err
+}
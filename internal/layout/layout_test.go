@@ -0,0 +1,114 @@
+package layout
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected int
+	}{
+		{"ascii", "main.go", 7},
+		{"wide", "日本語.txt", 10},
+		{"fullwidth", "ＡＢ", 4},
+		{"combining", "café", 4},
+		{"zero width joiner", "a‍b", 2},
+		{"coloured", "\x1b[01;34mdir\x1b[0m", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Width(tt.input))
+		})
+	}
+}
+
+func TestWriteColumns(t *testing.T) {
+	names := []string{"a", "bb", "ccc", "d", "eeeee", "f", "g"}
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, names, Columns, 20))
+	assert.Equal(t, ""+
+		"a   ccc  eeeee  g\n"+
+		"bb  d    f\n", buf.String())
+}
+
+func TestWriteAcross(t *testing.T) {
+	names := []string{"a", "bb", "ccc", "d", "eeeee", "f", "g"}
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, names, Across, 20))
+	assert.Equal(t, ""+
+		"a  bb  ccc  d  eeeee\n"+
+		"f  g\n", buf.String())
+}
+
+func TestWriteColumnsWideNames(t *testing.T) {
+	names := []string{"日本", "a", "b", "c"}
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, names, Columns, 9))
+	assert.Equal(t, ""+
+		"日本  b\n"+
+		"a     c\n", buf.String())
+}
+
+func TestWriteColumnsTooNarrow(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, []string{"alpha", "beta"}, Columns, 3))
+	assert.Equal(t, "alpha\nbeta\n", buf.String())
+}
+
+func TestFitManyNames(t *testing.T) {
+	// One cell names and their gaps fill an 80 cell line with 27 columns,
+	// which bounds the search however many names there are.
+	widths := make([]int, 20000)
+	for i := range widths {
+		widths[i] = 1
+	}
+	rows, colWidths := fit(widths, false, 80)
+	assert.Len(t, colWidths, 27)
+	assert.Equal(t, 741, rows)
+}
+
+func TestWriteOnePerLine(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, []string{"a", "b"}, OnePerLine, 80))
+	assert.Equal(t, "a\nb\n", buf.String())
+}
+
+func TestWriteCommas(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, []string{"alpha", "beta", "gamma", "delta"}, Commas, 20))
+	assert.Equal(t, "alpha, beta, gamma,\ndelta\n", buf.String())
+}
+
+func TestTerminalWidth(t *testing.T) {
+	noTTY := func() (int, bool) { return 0, false }
+	env := func(cols string) func(string) string {
+		return func(string) string { return cols }
+	}
+
+	assert.Equal(t, 132, TerminalWidthWithDeps(func() (int, bool) { return 132, true }, env("90")))
+	assert.Equal(t, 90, TerminalWidthWithDeps(noTTY, env("90")))
+	assert.Equal(t, DefaultWidth, TerminalWidthWithDeps(noTTY, env("")))
+	assert.Equal(t, DefaultWidth, TerminalWidthWithDeps(noTTY, env("wide")))
+}
+
+func TestWriteTable(t *testing.T) {
+	rows := [][]string{
+		{"-rw-r--r--", "1", "root", "12", "a"},
+		{"drwxr-xr-x", "12", "日本", "4096", "dir"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteTable(&buf, rows, []Align{Left, Right, Left, Right, Left}))
+	assert.Equal(t, ""+
+		"-rw-r--r--  1 root   12 a\n"+
+		"drwxr-xr-x 12 日本 4096 dir\n", buf.String())
+}
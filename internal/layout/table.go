@@ -0,0 +1,53 @@
+package layout
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Align says which side of a table column cells are padded on.
+type Align int
+
+const (
+	Left Align = iota
+	Right
+)
+
+// WriteTable writes rows with every column padded to its widest cell and
+// a single space between columns, the way ls -l lines up its fields. The
+// last column is never padded so long names do not leave trailing space.
+func WriteTable(w io.Writer, rows [][]string, aligns []Align) error {
+	var widths []int
+	for _, row := range rows {
+		for col, cell := range row {
+			if col >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[col] = max(widths[col], Width(cell))
+		}
+	}
+
+	var line strings.Builder
+	for _, row := range rows {
+		line.Reset()
+		for col, cell := range row {
+			if col > 0 {
+				line.WriteByte(' ')
+			}
+			pad := strings.Repeat(" ", widths[col]-Width(cell))
+			switch {
+			case col == len(row)-1 && (col >= len(aligns) || aligns[col] == Left):
+				line.WriteString(cell)
+			case col < len(aligns) && aligns[col] == Right:
+				line.WriteString(pad + cell)
+			default:
+				line.WriteString(cell + pad)
+			}
+		}
+		if _, err := fmt.Fprintln(w, line.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package layout
+
+import (
+	"os"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// DefaultWidth is used when neither the terminal nor COLUMNS say otherwise.
+const DefaultWidth = 80
+
+// TerminalWidth returns the width of the terminal on f, asking the kernel
+// with TIOCGWINSZ first and falling back to COLUMNS and then DefaultWidth.
+func TerminalWidth(f *os.File) int {
+	return TerminalWidthWithDeps(func() (int, bool) { return ioctlWidth(f.Fd()) }, os.Getenv)
+}
+
+func TerminalWidthWithDeps(ioctl func() (int, bool), getenv func(string) string) int {
+	if cols, ok := ioctl(); ok {
+		return cols
+	}
+	if cols, err := strconv.Atoi(getenv("COLUMNS")); err == nil && cols > 0 {
+		return cols
+	}
+	return DefaultWidth
+}
+
+func ioctlWidth(fd uintptr) (int, bool) {
+	ws, err := unix.IoctlGetWinsize(int(fd), unix.TIOCGWINSZ)
+	if err != nil || ws.Col == 0 {
+		return 0, false
+	}
+	return int(ws.Col), true
+}
@@ -0,0 +1,141 @@
+// Package mount reads the mount table of the running system, to tell which
+// file system each path lives on.
+package mount
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Mount is one line of a mountinfo table, as described in proc(5), or one
+// file system listed by getfsstat(2) on Darwin.
+type Mount struct {
+	ID       int `json:"id"`
+	ParentID int `json:"parent_id"`
+	// Root is the directory of the source file system mounted, which is
+	// not / for bind mounts of a subdirectory.
+	Root       string   `json:"root"`
+	MountPoint string   `json:"mount_point"`
+	Options    []string `json:"options"`
+	FSType     string   `json:"fs_type"`
+	Source     string   `json:"source"`
+	// SuperOptions are the options of the file system rather than of this
+	// mount of it.
+	SuperOptions []string `json:"super_options"`
+}
+
+// Table is a mount table in the order mounts were made, so that a later
+// mount on the same point hides an earlier one.
+type Table []Mount
+
+// Parse reads a table in the format of /proc/self/mountinfo.
+func Parse(r io.Reader) (Table, error) {
+	var t Table
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		m, err := parseLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		t = append(t, m)
+	}
+	return t, scanner.Err()
+}
+
+// parseLine parses one mount. The optional fields between the mount options
+// and the "-" separator, such as shared:1, are skipped.
+func parseLine(line string) (Mount, error) {
+	fields := strings.Fields(line)
+	sep := -1
+	for i := 6; i < len(fields); i++ {
+		if fields[i] == "-" {
+			sep = i
+			break
+		}
+	}
+	if sep < 0 || len(fields) < sep+3 {
+		return Mount{}, errors.New("malformed mountinfo line")
+	}
+	var m Mount
+	var err error
+	if m.ID, err = strconv.Atoi(fields[0]); err != nil {
+		return Mount{}, fmt.Errorf("invalid mount id: %w", err)
+	}
+	if m.ParentID, err = strconv.Atoi(fields[1]); err != nil {
+		return Mount{}, fmt.Errorf("invalid parent id: %w", err)
+	}
+	m.Root = unescape(fields[3])
+	m.MountPoint = unescape(fields[4])
+	m.Options = strings.Split(fields[5], ",")
+	m.FSType = unescape(fields[sep+1])
+	m.Source = unescape(fields[sep+2])
+	if len(fields) > sep+3 {
+		m.SuperOptions = strings.Split(fields[sep+3], ",")
+	}
+	return m, nil
+}
+
+// unescape decodes the octal escapes the kernel writes for spaces, tabs,
+// newlines and backslashes in paths, e.g. \040 for a space.
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if c, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// Lookup returns the mount that path, which must be absolute, lives on: the
+// latest one whose mount point is path or one of its ancestors. It returns
+// nil when no mount contains path.
+func (t Table) Lookup(path string) *Mount {
+	path = filepath.Clean(path)
+	var found *Mount
+	for i := range t {
+		m := &t[i]
+		if !contains(m.MountPoint, path) {
+			continue
+		}
+		if found == nil || len(m.MountPoint) >= len(found.MountPoint) {
+			found = m
+		}
+	}
+	return found
+}
+
+// IsMountPoint reports whether something is mounted on path.
+func (t Table) IsMountPoint(path string) bool {
+	path = filepath.Clean(path)
+	for _, m := range t {
+		if m.MountPoint == path {
+			return true
+		}
+	}
+	return false
+}
+
+// contains reports whether path is dir or beneath it.
+func contains(dir, path string) bool {
+	if dir == "/" || dir == path {
+		return true
+	}
+	return strings.HasPrefix(path, dir) && path[len(dir)] == '/'
+}
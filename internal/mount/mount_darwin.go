@@ -0,0 +1,31 @@
+package mount
+
+import (
+	"github.com/sochoa/go-ls/internal/fsinfo"
+	"golang.org/x/sys/unix"
+)
+
+// Load reads the mount table with getfsstat(2). Darwin has no mount IDs or
+// bind mounts, so ID and ParentID are left zero and every Root is /.
+func Load() (Table, error) {
+	n, err := unix.Getfsstat(nil, unix.MNT_NOWAIT)
+	if err != nil {
+		return nil, err
+	}
+	// Mounts made between the two calls are left out.
+	buf := make([]unix.Statfs_t, n)
+	if n, err = unix.Getfsstat(buf, unix.MNT_NOWAIT); err != nil {
+		return nil, err
+	}
+	t := make(Table, 0, n)
+	for _, s := range buf[:n] {
+		t = append(t, Mount{
+			Root:       "/",
+			MountPoint: unix.ByteSliceToString(s.Mntonname[:]),
+			Options:    fsinfo.FlagNames(s.Flags),
+			FSType:     unix.ByteSliceToString(s.Fstypename[:]),
+			Source:     unix.ByteSliceToString(s.Mntfromname[:]),
+		})
+	}
+	return t, nil
+}
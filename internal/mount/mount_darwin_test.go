@@ -0,0 +1,25 @@
+package mount
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadDarwin(t *testing.T) {
+	table, err := Load()
+	require.NoError(t, err)
+
+	root := table.Lookup("/")
+	require.NotNil(t, root)
+	require.Equal(t, "/", root.MountPoint)
+	require.NotEmpty(t, root.FSType)
+	require.Contains(t, []string{"ro", "rw"}, root.Options[0])
+
+	// /dev is devfs, mounted over the root file system.
+	dev := table.Lookup("/dev/null")
+	require.NotNil(t, dev)
+	require.Equal(t, "/dev", dev.MountPoint)
+	require.Equal(t, "devfs", dev.FSType)
+	require.True(t, table.IsMountPoint("/dev"))
+}
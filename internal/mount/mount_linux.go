@@ -0,0 +1,13 @@
+package mount
+
+import "os"
+
+// Load reads the mount table of the calling process.
+func Load() (Table, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
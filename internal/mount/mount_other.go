@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package mount
+
+import "errors"
+
+// Load is only implemented for Linux and Darwin.
+func Load() (Table, error) {
+	return nil, errors.ErrUnsupported
+}
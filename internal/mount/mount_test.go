@@ -0,0 +1,79 @@
+package mount
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func fixture(t *testing.T) Table {
+	f, err := os.Open("testdata/mountinfo")
+	require.NoError(t, err)
+	defer f.Close()
+	table, err := Parse(f)
+	require.NoError(t, err)
+	return table
+}
+
+func TestParse(t *testing.T) {
+	table := fixture(t)
+	require.Len(t, table, 7)
+
+	require.Equal(t, Mount{
+		ID:           25,
+		ParentID:     22,
+		Root:         "/",
+		MountPoint:   "/srv/nfs",
+		Options:      []string{"rw", "relatime"},
+		FSType:       "nfs4",
+		Source:       "fileserver:/export/home",
+		SuperOptions: []string{"rw", "vers=4.2", "rsize=1048576"},
+	}, table[3])
+	require.Equal(t, "overlay", table[4].FSType)
+
+	// Spaces in paths are escaped as \040.
+	require.Equal(t, "/home/user/My Disk", table[5].Root)
+	require.Equal(t, "/media/My Disk", table[5].MountPoint)
+}
+
+func TestParseMalformed(t *testing.T) {
+	_, err := Parse(strings.NewReader("22 1 254:0 / / rw,relatime shared:1 ext4 /dev/vda1 rw\n"))
+	require.EqualError(t, err, "line 1: malformed mountinfo line")
+	_, err = Parse(strings.NewReader("x 1 254:0 / / rw - ext4 /dev/vda1 rw\n"))
+	require.ErrorContains(t, err, "invalid mount id")
+}
+
+func TestLookup(t *testing.T) {
+	table := fixture(t)
+	for path, want := range map[string]int{
+		"/":                 22,
+		"/etc/passwd":       22,
+		"/srv":              22,
+		"/srv/nfs":          25,
+		"/srv/nfs/a/b":      25,
+		"/srv/nfsx":         22,
+		"/media/My Disk/x":  27,
+		"/proc/self/status": 23,
+		// The later mount on /tmp hides the earlier one.
+		"/tmp/f": 28,
+	} {
+		m := table.Lookup(path)
+		require.NotNil(t, m, path)
+		require.Equal(t, want, m.ID, path)
+	}
+	require.Nil(t, Table{}.Lookup("/"))
+
+	require.True(t, table.IsMountPoint("/srv/nfs/"))
+	require.True(t, table.IsMountPoint("/"))
+	require.False(t, table.IsMountPoint("/srv"))
+}
+
+func TestLoad(t *testing.T) {
+	table, err := Load()
+	if err != nil {
+		t.Skipf("no mount table: %v", err)
+	}
+	require.NotNil(t, table.Lookup("/"))
+}
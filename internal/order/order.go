@@ -0,0 +1,75 @@
+package order
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/sochoa/go-ls/internal/stat"
+)
+
+// Sort keys accepted by --sort, named as in GNU ls.
+const (
+	None      = "none"
+	Name      = "name"
+	Size      = "size"
+	Time      = "time"
+	Extension = "extension"
+)
+
+// Options describes how entries are ordered.
+type Options struct {
+	// Key is one of the sort keys above.
+	Key string
+	// Reverse reverses the order.
+	Reverse bool
+	// Time returns the timestamp compared by the Time key.
+	Time func(stat.Stat) time.Time
+}
+
+// ParseKey validates a --sort value. An empty value means Name.
+func ParseKey(s string) (string, error) {
+	switch s {
+	case "":
+		return Name, nil
+	case None, Name, Size, Time, Extension:
+		return s, nil
+	}
+	return "", fmt.Errorf("invalid sort %q, expected none, name, size, time or extension", s)
+}
+
+// Sort orders entries in place, getting the metadata of each with get. As
+// with ls, sizes sort largest first and times newest first, and ties are
+// broken by name. None keeps the order entries were read in, even when
+// Reverse is set.
+func Sort[E any](entries []E, get func(E) stat.Stat, opts Options) {
+	if opts.Key == None {
+		return
+	}
+	compare := func(a, b stat.Stat) int {
+		var c int
+		switch opts.Key {
+		case Size:
+			c = cmp.Compare(b.SizeBytes, a.SizeBytes)
+		case Time:
+			if opts.Time != nil {
+				c = opts.Time(b).Compare(opts.Time(a))
+			}
+		case Extension:
+			c = cmp.Compare(filepath.Ext(a.BaseName), filepath.Ext(b.BaseName))
+		}
+		if c == 0 {
+			c = cmp.Compare(a.BaseName, b.BaseName)
+		}
+		return c
+	}
+	slices.SortStableFunc(entries, func(a, b E) int {
+		c := compare(get(a), get(b))
+		if opts.Reverse {
+			return -c
+		}
+		return c
+	})
+}
@@ -0,0 +1,60 @@
+package order
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sochoa/go-ls/internal/stat"
+	"github.com/stretchr/testify/require"
+)
+
+func entries() []stat.Stat {
+	day := func(d int) stat.Time { return stat.Time{Time: time.Date(2024, time.January, d, 0, 0, 0, 0, time.UTC)} }
+	return []stat.Stat{
+		{BaseName: "b.txt", SizeBytes: 10, LastModifiedTime: day(2)},
+		{BaseName: "a.go", SizeBytes: 30, LastModifiedTime: day(1)},
+		{BaseName: "c", SizeBytes: 10, LastModifiedTime: day(3)},
+		{BaseName: "d.go", SizeBytes: 20, LastModifiedTime: day(2)},
+	}
+}
+
+func names(s []stat.Stat) []string {
+	var n []string
+	for _, e := range s {
+		n = append(n, e.BaseName)
+	}
+	return n
+}
+
+func TestSort(t *testing.T) {
+	modified := func(s stat.Stat) time.Time { return s.LastModifiedTime.Time }
+	tests := []struct {
+		opts Options
+		want []string
+	}{
+		{Options{Key: Name}, []string{"a.go", "b.txt", "c", "d.go"}},
+		{Options{Key: Name, Reverse: true}, []string{"d.go", "c", "b.txt", "a.go"}},
+		{Options{Key: None, Reverse: true}, []string{"b.txt", "a.go", "c", "d.go"}},
+		{Options{Key: Size}, []string{"a.go", "d.go", "b.txt", "c"}},
+		{Options{Key: Time, Time: modified}, []string{"c", "b.txt", "d.go", "a.go"}},
+		{Options{Key: Extension}, []string{"c", "a.go", "d.go", "b.txt"}},
+	}
+	for _, tt := range tests {
+		s := entries()
+		Sort(s, func(e stat.Stat) stat.Stat { return e }, tt.opts)
+		require.Equal(t, tt.want, names(s), "%+v", tt.opts)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	key, err := ParseKey("")
+	require.NoError(t, err)
+	require.Equal(t, Name, key)
+
+	key, err = ParseKey("size")
+	require.NoError(t, err)
+	require.Equal(t, Size, key)
+
+	_, err = ParseKey("width")
+	require.Error(t, err)
+}
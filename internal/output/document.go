@@ -0,0 +1,181 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/sochoa/go-ls/internal/stat"
+	"gopkg.in/yaml.v3"
+)
+
+// Node is an entry along with the entries listed beneath it, so that a
+// directory can be rendered with its contents nested inside.
+type Node struct {
+	Entry    stat.CommonStat
+	Children []Node
+	// Name is how the node is shown in a tree; its base name when empty.
+	Name string
+}
+
+// childrenKey holds a directory's nested entries in YAML and TOML output.
+const childrenKey = "children"
+
+// entryJson returns the JSON encoding of m that the documents are built from,
+// so field names and timestamp encodings always match the JSON output.
+func entryJson(m stat.CommonStat) ([]byte, error) {
+	s, err := m.Json(false)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
+
+// YAML writes nodes as a YAML sequence. Fields keep the order of the JSON
+// encoding; a node with children gains a "children" sequence.
+func YAML(w io.Writer, nodes []Node) error {
+	doc, err := yamlSequence(nodes)
+	if err != nil {
+		return err
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write yaml: %w", err)
+	}
+	return enc.Close()
+}
+
+func yamlSequence(nodes []Node) (*yaml.Node, error) {
+	seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, n := range nodes {
+		b, err := entryJson(n.Entry)
+		if err != nil {
+			return nil, err
+		}
+		// JSON is YAML, so decoding it keeps the field order and types.
+		var doc yaml.Node
+		if err := yaml.Unmarshal(b, &doc); err != nil {
+			return nil, fmt.Errorf("failed to convert %s to yaml: %w", n.Entry.GetAbsolutePath(), err)
+		}
+		entry := doc.Content[0]
+		blockStyle(entry)
+		if n.Children != nil {
+			children, err := yamlSequence(n.Children)
+			if err != nil {
+				return nil, err
+			}
+			entry.Content = append(entry.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: childrenKey}, children)
+		}
+		seq.Content = append(seq.Content, entry)
+	}
+	return seq, nil
+}
+
+// blockStyle drops the flow and quoting styles carried over from JSON,
+// leaving the encoder to pick plain block style wherever it can.
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
+
+// TOML writes nodes as an array of tables named "entries", with children
+// as nested arrays of tables. TOML has no ordered maps, so keys are sorted,
+// and RFC 3339 timestamps are written as TOML date-times.
+func TOML(w io.Writer, nodes []Node) error {
+	entries, err := tomlTables(nodes)
+	if err != nil {
+		return err
+	}
+	if err := toml.NewEncoder(w).Encode(map[string]any{"entries": entries}); err != nil {
+		return fmt.Errorf("failed to write toml: %w", err)
+	}
+	return nil
+}
+
+func tomlTables(nodes []Node) ([]map[string]any, error) {
+	tables := make([]map[string]any, 0, len(nodes))
+	for _, n := range nodes {
+		b, err := entryJson(n.Entry)
+		if err != nil {
+			return nil, err
+		}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		var entry map[string]any
+		if err := dec.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("failed to convert %s to toml: %w", n.Entry.GetAbsolutePath(), err)
+		}
+		table := tomlValue(entry).(map[string]any)
+		for _, key := range timeKeys {
+			if v, ok := table[key].(string); ok {
+				if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+					table[key] = t
+				}
+			}
+		}
+		if n.Children != nil {
+			children, err := tomlTables(n.Children)
+			if err != nil {
+				return nil, err
+			}
+			table[childrenKey] = children
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// timeKeys are the JSON keys of the stat.Time fields of an entry.
+var timeKeys = func() []string {
+	var keys []string
+	for _, f := range stat.Fields(reflect.TypeOf(stat.Stat{})) {
+		if f.Type == reflect.TypeOf(stat.Time{}) {
+			keys = append(keys, f.Name)
+		}
+	}
+	return keys
+}()
+
+// tomlValue converts a decoded JSON value into one the TOML encoder writes
+// natively. TOML has no null, so null fields are dropped.
+func tomlValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if child == nil {
+				delete(v, k)
+				continue
+			}
+			v[k] = tomlValue(child)
+		}
+		return v
+	case []any:
+		for i, child := range v {
+			v[i] = tomlValue(child)
+		}
+		return v
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		return tomlNumber(v)
+	}
+	return v
+}
+
+// tomlNumber is a JSON number written to TOML as it is, which is a valid
+// TOML float, so that no digits are lost to float64, as they would be from
+// the fraction of an epoch timestamp.
+type tomlNumber json.Number
+
+func (n tomlNumber) MarshalTOML() ([]byte, error) {
+	return []byte(n), nil
+}
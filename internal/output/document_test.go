@@ -0,0 +1,96 @@
+package output
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sochoa/go-ls/internal/stat"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// documentNodes is a directory holding a file and a symbolic link.
+func documentNodes() []Node {
+	dir := templateEntry()
+	dir.Type = stat.DirectoryFileType
+	dir.Mode = syscall.S_IFDIR | 0o755
+	dir.BaseName = "alice"
+	dir.AbsolutePath = "/home/alice"
+	dir.Permissions.Octal = "755"
+
+	link := stat.StatLink{Stat: templateEntry(), Targets: []string{"/home/alice/latest", "/home/alice/notes.txt"}}
+	link.Type = stat.SymbolicLinkFileType
+	link.BaseName = "latest"
+	link.AbsolutePath = "/home/alice/latest"
+	link.LastModifiedTime.Time = link.LastModifiedTime.Add(250 * time.Millisecond)
+
+	return []Node{{
+		Entry: dir,
+		Children: []Node{
+			{Entry: templateEntry()},
+			{Entry: link},
+		},
+	}}
+}
+
+// withEpochTimes sets every timestamp in nodes to encode as Unix seconds.
+func withEpochTimes(nodes []Node) []Node {
+	for i := range nodes {
+		switch e := nodes[i].Entry.(type) {
+		case stat.Stat:
+			for _, t := range e.Times() {
+				t.Epoch = true
+			}
+			nodes[i].Entry = e
+		case stat.StatLink:
+			for _, t := range e.Times() {
+				t.Epoch = true
+			}
+			nodes[i].Entry = e
+		}
+		nodes[i].Children = withEpochTimes(nodes[i].Children)
+	}
+	return nodes
+}
+
+func requireGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, string(want), string(got))
+}
+
+func TestDocuments(t *testing.T) {
+	tests := []struct {
+		golden string
+		render func(io.Writer, []Node) error
+		epoch  bool
+	}{
+		{golden: "entries.yaml", render: YAML},
+		{golden: "entries_epoch.yaml", render: YAML, epoch: true},
+		{golden: "entries.toml", render: TOML},
+		{golden: "entries_epoch.toml", render: TOML, epoch: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			nodes := documentNodes()
+			if tt.epoch {
+				nodes = withEpochTimes(nodes)
+			}
+			var buf bytes.Buffer
+			require.NoError(t, tt.render(&buf, nodes))
+			requireGolden(t, tt.golden, buf.Bytes())
+		})
+	}
+}
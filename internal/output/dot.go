@@ -0,0 +1,75 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/sochoa/go-ls/internal/stat"
+)
+
+// Dot writes the symbolic link graph of links in Graphviz DOT format. Every
+// hop of a link chain becomes an edge; links are drawn as ellipses, the
+// paths they end on as boxes, and missing targets dashed in red.
+func Dot(w io.Writer, links []stat.StatLink) error {
+	var (
+		nodes     = map[string]string{}
+		order     []string
+		edges     = map[[2]string]bool{}
+		edgeOrder [][2]string
+	)
+	addNode := func(path, attrs string) {
+		if _, ok := nodes[path]; !ok {
+			order = append(order, path)
+		}
+		// A path seen as a link somewhere keeps the link style.
+		if nodes[path] != linkNodeAttrs {
+			nodes[path] = attrs
+		}
+	}
+	for _, l := range links {
+		for i, target := range l.Targets {
+			switch {
+			case i < len(l.Targets)-1:
+				addNode(target, linkNodeAttrs)
+			case l.Dangling:
+				addNode(target, danglingNodeAttrs)
+			default:
+				addNode(target, targetNodeAttrs)
+			}
+			if i == 0 {
+				continue
+			}
+			edge := [2]string{l.Targets[i-1], target}
+			if !edges[edge] {
+				edges[edge] = true
+				edgeOrder = append(edgeOrder, edge)
+			}
+		}
+	}
+
+	if _, err := fmt.Fprintln(w, "digraph symlinks {"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "  rankdir=LR;"); err != nil {
+		return err
+	}
+	for _, path := range order {
+		if _, err := fmt.Fprintf(w, "  %s [%s];\n", strconv.Quote(path), nodes[path]); err != nil {
+			return err
+		}
+	}
+	for _, edge := range edgeOrder {
+		if _, err := fmt.Fprintf(w, "  %s -> %s;\n", strconv.Quote(edge[0]), strconv.Quote(edge[1])); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
+
+const (
+	linkNodeAttrs     = "shape=ellipse"
+	targetNodeAttrs   = "shape=box"
+	danglingNodeAttrs = "shape=box, style=dashed, color=red"
+)
@@ -0,0 +1,32 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sochoa/go-ls/internal/stat"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDot(t *testing.T) {
+	links := []stat.StatLink{
+		{Targets: []string{"/srv/current", "/srv/releases/3"}},
+		{Targets: []string{"/srv/previous", "/srv/current", "/srv/releases/3"}},
+		{Targets: []string{"/srv/old", "/srv/releases/1"}, Dangling: true},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Dot(&buf, links))
+	require.Equal(t, `digraph symlinks {
+  rankdir=LR;
+  "/srv/current" [shape=ellipse];
+  "/srv/releases/3" [shape=box];
+  "/srv/previous" [shape=ellipse];
+  "/srv/old" [shape=ellipse];
+  "/srv/releases/1" [shape=box, style=dashed, color=red];
+  "/srv/current" -> "/srv/releases/3";
+  "/srv/previous" -> "/srv/current";
+  "/srv/old" -> "/srv/releases/1";
+}
+`, buf.String())
+}
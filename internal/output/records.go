@@ -0,0 +1,230 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/sochoa/go-ls/internal/stat"
+)
+
+// RecordOptions selects the record format and the fields written.
+type RecordOptions struct {
+	// TSV writes tab separated values instead of RFC 4180 CSV.
+	TSV bool
+	// Columns lists the columns to write, in order. A nested object such as
+	// "permissions.symbolic" selects every column beneath it. Empty means
+	// all columns.
+	Columns []string
+	// Type is the struct type of the entries written, whose leaf fields are
+	// the columns. Nil means stat.StatLink, which every entry converts to.
+	Type reflect.Type
+}
+
+// column is a leaf field of stat.StatLink, or one key of a map field.
+type column struct {
+	stat.Field
+	key string
+}
+
+// allColumns is every column, in the order of the JSON encoding.
+var allColumns = columnsOf(stat.Leaves())
+
+func columnsOf(leaves []stat.Field) []column {
+	var columns []column
+	for _, f := range leaves {
+		columns = append(columns, column{Field: f})
+	}
+	return columns
+}
+
+// Columns returns the names of every column RecordWriter can write. Names
+// are the JSON keys of the field and its parents joined with dots, e.g.
+// "permissions.symbolic.owner.Read". A map field such as "hashes" is one
+// column, and "hashes.sha256" selects a single key of it.
+func Columns() []string {
+	names := make([]string, len(allColumns))
+	for i, c := range allColumns {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// selectColumns resolves names against the columns in from, all of them
+// when names is empty. Names are matched without regard to case, as fields
+// are in --where.
+func selectColumns(from []column, names []string) ([]column, error) {
+	if len(names) == 0 {
+		return from, nil
+	}
+	var selected []column
+	for _, name := range names {
+		found := false
+		lower := strings.ToLower(name)
+		for _, c := range from {
+			cname := strings.ToLower(c.Name)
+			if cname == lower || strings.HasPrefix(cname, lower+".") {
+				selected = append(selected, c)
+				found = true
+			} else if strings.HasPrefix(lower, cname+".") && c.Type.Kind() == reflect.Map {
+				c.key = name[len(c.Name)+1:]
+				c.Name += "." + c.key
+				selected = append(selected, c)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+	}
+	return selected, nil
+}
+
+// SelectsColumn reports whether the columns selected by names, as in
+// RecordOptions.Columns, include field or a column beneath it.
+func SelectsColumn(names []string, field string) bool {
+	columns, err := selectColumns(allColumns, names)
+	if err != nil {
+		return false
+	}
+	field = strings.ToLower(field)
+	for _, c := range columns {
+		name := strings.ToLower(c.Name)
+		if name == field || strings.HasPrefix(name, field+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordWriter writes entries as CSV or TSV records, one per entry, after a
+// header row of column names.
+type RecordWriter struct {
+	w       io.Writer
+	csv     *csv.Writer
+	columns []column
+	typ     reflect.Type
+	header  bool
+}
+
+// NewRecordWriter returns a RecordWriter writing to w. It fails when a
+// requested column does not exist.
+func NewRecordWriter(w io.Writer, opts RecordOptions) (*RecordWriter, error) {
+	typ, all := reflect.TypeOf(stat.StatLink{}), allColumns
+	if opts.Type != nil {
+		typ, all = opts.Type, columnsOf(stat.LeavesOf(opts.Type))
+	}
+	columns, err := selectColumns(all, opts.Columns)
+	if err != nil {
+		return nil, err
+	}
+	r := &RecordWriter{w: w, columns: columns, typ: typ}
+	if !opts.TSV {
+		r.csv = csv.NewWriter(w)
+	}
+	return r, nil
+}
+
+// Write writes the record for m, preceded by the header row on first use.
+func (r *RecordWriter) Write(m stat.CommonStat) error {
+	if err := r.writeHeader(); err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(m)
+	if v.Type() != r.typ {
+		link, ok := m.(stat.StatLink)
+		if !ok {
+			link = stat.StatLink{Stat: m.GetStat()}
+		}
+		v = reflect.ValueOf(link)
+	}
+	record := make([]string, len(r.columns))
+	for i, c := range r.columns {
+		field, err := v.FieldByIndexErr(c.Index)
+		if err != nil {
+			continue
+		}
+		if c.key != "" {
+			field = field.MapIndex(reflect.ValueOf(c.key))
+			if !field.IsValid() {
+				continue
+			}
+		}
+		s, err := r.formatValue(field)
+		if err != nil {
+			return fmt.Errorf("error formatting %s: %w", m.GetAbsolutePath(), err)
+		}
+		record[i] = s
+	}
+	return r.writeRecord(record)
+}
+
+// Flush writes any buffered records. It writes the header alone when no
+// entry was written, so an empty listing still has its schema.
+func (r *RecordWriter) Flush() error {
+	if err := r.writeHeader(); err != nil {
+		return err
+	}
+	if r.csv != nil {
+		r.csv.Flush()
+		return r.csv.Error()
+	}
+	return nil
+}
+
+func (r *RecordWriter) writeHeader() error {
+	if r.header {
+		return nil
+	}
+	r.header = true
+	names := make([]string, len(r.columns))
+	for i, c := range r.columns {
+		names[i] = c.Name
+	}
+	return r.writeRecord(names)
+}
+
+func (r *RecordWriter) writeRecord(record []string) error {
+	if r.csv != nil {
+		return r.csv.Write(record)
+	}
+	for i, field := range record {
+		record[i] = tsvEscaper.Replace(field)
+	}
+	_, err := io.WriteString(r.w, strings.Join(record, "\t")+"\n")
+	return err
+}
+
+// tsvEscaper escapes the characters that cannot appear in a TSV field the
+// way PostgreSQL's text format and DuckDB's reader expect.
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// formatValue renders a leaf field. Timestamps are written as in JSON, lists
+// and maps as JSON, and empty ones as an empty field.
+func (r *RecordWriter) formatValue(v reflect.Value) (string, error) {
+	if t, ok := v.Interface().(stat.Time); ok {
+		b, err := t.MarshalText()
+		return string(b), err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Slice, reflect.Map:
+		if v.Len() == 0 {
+			return "", nil
+		}
+	}
+	b, err := json.Marshal(v.Interface())
+	return string(b), err
+}
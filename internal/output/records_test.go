@@ -0,0 +1,150 @@
+package output
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sochoa/go-ls/internal/elfinfo"
+	"github.com/sochoa/go-ls/internal/stat"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumns(t *testing.T) {
+	columns := Columns()
+	require.Equal(t, []string{"schema_version", "size_bytes"}, columns[:2])
+	require.Contains(t, columns, "permissions.octal")
+	require.Contains(t, columns, "permissions.symbolic.owner.Read")
+	require.Contains(t, columns, "last_modified_time")
+	require.Equal(t, []string{"targets", "dangling"}, columns[len(columns)-2:])
+}
+
+func TestRecordWriterCSV(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := NewRecordWriter(&buf, RecordOptions{
+		Columns: []string{"basename", "permissions.symbolic.owner", "targets", "last_modified_time"},
+	})
+	require.NoError(t, err)
+
+	entry := templateEntry()
+	entry.BaseName = `say "hi", there`
+	require.NoError(t, r.Write(entry))
+	link := stat.StatLink{Stat: templateEntry(), Targets: []string{"/a", "/b"}}
+	require.NoError(t, r.Write(link))
+	require.NoError(t, r.Flush())
+
+	require.Equal(t, "basename,permissions.symbolic.owner.Read,permissions.symbolic.owner.Write,"+
+		"permissions.symbolic.owner.Execute,targets,last_modified_time\n"+
+		`"say ""hi"", there",true,true,false,,2024-03-14T15:09:26Z`+"\n"+
+		`notes.txt,true,true,false,"[""/a"",""/b""]",2024-03-14T15:09:26Z`+"\n",
+		buf.String())
+}
+
+func TestRecordWriterTSV(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := NewRecordWriter(&buf, RecordOptions{
+		TSV:     true,
+		Columns: []string{"basename", "size_bytes", "last_modified_time"},
+	})
+	require.NoError(t, err)
+
+	entry := templateEntry()
+	entry.BaseName = "tab\there"
+	entry.LastModifiedTime.Time = entry.LastModifiedTime.Add(250 * time.Millisecond)
+	entry.LastModifiedTime.Epoch = true
+	require.NoError(t, r.Write(entry))
+	require.NoError(t, r.Flush())
+	require.Equal(t, "basename\tsize_bytes\tlast_modified_time\ntab\\there\t4096\t1710428966.25\n", buf.String())
+}
+
+func TestRecordWriterMapColumns(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := NewRecordWriter(&buf, RecordOptions{Columns: []string{"basename", "hashes.sha1", "hashes"}})
+	require.NoError(t, err)
+
+	entry := templateEntry()
+	entry.Hashes = map[string]string{"sha1": "da39", "md5": "d41d"}
+	require.NoError(t, r.Write(entry))
+	require.NoError(t, r.Write(templateEntry()))
+	require.NoError(t, r.Flush())
+	require.Equal(t, "basename,hashes.sha1,hashes\n"+
+		`notes.txt,da39,"{""md5"":""d41d"",""sha1"":""da39""}"`+"\n"+
+		"notes.txt,,\n", buf.String())
+
+	_, err = NewRecordWriter(&buf, RecordOptions{Columns: []string{"basename.sha1"}})
+	require.Error(t, err)
+}
+
+func TestSelectsColumn(t *testing.T) {
+	require.True(t, SelectsColumn(nil, "hashes"))
+	require.True(t, SelectsColumn([]string{"basename", "hashes.sha1"}, "hashes"))
+	require.True(t, SelectsColumn([]string{"Hashes"}, "hashes"))
+	require.True(t, SelectsColumn([]string{"elf.type"}, "elf"))
+	require.False(t, SelectsColumn([]string{"basename", "size_bytes"}, "hashes"))
+	require.False(t, SelectsColumn([]string{"nonsense"}, "hashes"))
+}
+
+func TestRecordWriterColumnCase(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := NewRecordWriter(&buf, RecordOptions{Columns: []string{"BaseName", "permissions.symbolic.owner.read", "Hashes.sha1"}})
+	require.NoError(t, err)
+
+	entry := templateEntry()
+	entry.Hashes = map[string]string{"sha1": "da39"}
+	require.NoError(t, r.Write(entry))
+	require.NoError(t, r.Flush())
+	require.Equal(t, "basename,permissions.symbolic.owner.Read,hashes.sha1\nnotes.txt,true,da39\n", buf.String())
+}
+
+func TestRecordWriterType(t *testing.T) {
+	type usageEntry struct {
+		stat.Stat
+		Usage struct {
+			Inodes uint64 `json:"inodes"`
+		} `json:"usage"`
+	}
+	var buf bytes.Buffer
+	r, err := NewRecordWriter(&buf, RecordOptions{
+		Columns: []string{"basename", "usage"},
+		Type:    reflect.TypeOf(usageEntry{}),
+	})
+	require.NoError(t, err)
+
+	entry := usageEntry{Stat: templateEntry()}
+	entry.Usage.Inodes = 3
+	require.NoError(t, r.Write(entry))
+	require.NoError(t, r.Flush())
+	require.Equal(t, "basename,usage.inodes\nnotes.txt,3\n", buf.String())
+
+	_, err = NewRecordWriter(&buf, RecordOptions{Columns: []string{"usage"}})
+	require.ErrorContains(t, err, "unknown column")
+}
+
+func TestRecordWriterOptionalObject(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := NewRecordWriter(&buf, RecordOptions{Columns: []string{"basename", "elf.type", "elf.needed", "elf.go.version"}})
+	require.NoError(t, err)
+
+	entry := templateEntry()
+	entry.ELF = &elfinfo.ELF{Type: "pie", Needed: []string{"libc.so.6"}}
+	require.NoError(t, r.Write(entry))
+	require.NoError(t, r.Write(templateEntry()))
+	require.NoError(t, r.Flush())
+	require.Equal(t, "basename,elf.type,elf.needed,elf.go.version\n"+
+		`notes.txt,pie,"[""libc.so.6""]",`+"\n"+
+		"notes.txt,,,\n", buf.String())
+}
+
+func TestRecordWriterEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := NewRecordWriter(&buf, RecordOptions{Columns: []string{"basename", "type"}})
+	require.NoError(t, err)
+	require.NoError(t, r.Flush())
+	require.Equal(t, "basename,type\n", buf.String())
+}
+
+func TestRecordWriterUnknownColumn(t *testing.T) {
+	_, err := NewRecordWriter(&bytes.Buffer{}, RecordOptions{Columns: []string{"nope"}})
+	require.Error(t, err)
+}
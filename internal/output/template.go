@@ -0,0 +1,173 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/sochoa/go-ls/internal/color"
+	"github.com/sochoa/go-ls/internal/size"
+	"github.com/sochoa/go-ls/internal/stat"
+	"github.com/sochoa/go-ls/internal/timefmt"
+)
+
+// TemplateOptions carries the settings the template helpers honour.
+type TemplateOptions struct {
+	// Size is the unit humanSize uses; a unit that is not human readable
+	// falls back to -h.
+	Size size.Unit
+	// Colors paints names for the color helper; nil leaves them plain.
+	Colors *color.Scheme
+	// Now is the reference time for the recent/old rule of timeFormat.
+	Now time.Time
+}
+
+// Template renders each entry with a text/template, one entry at a time.
+type Template struct {
+	tmpl *template.Template
+}
+
+// NewTemplate parses text with the helper functions available:
+//
+//	humanSize   size in bytes to e.g. 4.0K
+//	modeString  entry to its ls -l mode string, e.g. drwxr-xr-x
+//	timeFormat  a --time-style value or strftime format applied to a time
+//	color       entry and text to text painted with the entry's colour
+//	targets     entry to its symlink targets, empty for other entries
+//	join        strings joined with a separator
+func NewTemplate(text string, opts TemplateOptions) (*Template, error) {
+	tmpl, err := template.New("format").Funcs(opts.funcs()).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid format: %w", err)
+	}
+	return &Template{tmpl: tmpl}, nil
+}
+
+// Uses reports whether the template reads the entry field with the JSON key
+// field, e.g. hashes for {{.Hashes}}, so that callers can skip filling in
+// fields it never shows. A field named in a string, as with index, counts.
+func (t *Template) Uses(field string) bool {
+	entry := reflect.TypeOf(stat.StatLink{})
+	for _, f := range stat.Fields(entry) {
+		if f.Name != field {
+			continue
+		}
+		name := entry.FieldByIndex(f.Index).Name
+		for _, tmpl := range t.tmpl.Templates() {
+			if tmpl.Tree != nil && nodeUses(tmpl.Tree.Root, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nodeUses reports whether the template node n reads a field called name.
+func nodeUses(n parse.Node, name string) bool {
+	switch n := n.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return false
+		}
+		for _, c := range n.Nodes {
+			if nodeUses(c, name) {
+				return true
+			}
+		}
+	case *parse.PipeNode:
+		if n == nil {
+			return false
+		}
+		for _, c := range n.Cmds {
+			if nodeUses(c, name) {
+				return true
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if nodeUses(arg, name) {
+				return true
+			}
+		}
+	case *parse.ActionNode:
+		return nodeUses(n.Pipe, name)
+	case *parse.TemplateNode:
+		return nodeUses(n.Pipe, name)
+	case *parse.IfNode:
+		return branchUses(&n.BranchNode, name)
+	case *parse.RangeNode:
+		return branchUses(&n.BranchNode, name)
+	case *parse.WithNode:
+		return branchUses(&n.BranchNode, name)
+	case *parse.FieldNode:
+		return slices.Contains(n.Ident, name)
+	case *parse.VariableNode:
+		return slices.Contains(n.Ident, name)
+	case *parse.ChainNode:
+		return nodeUses(n.Node, name) || slices.Contains(n.Field, name)
+	case *parse.StringNode:
+		return n.Text == name
+	}
+	return false
+}
+
+func branchUses(n *parse.BranchNode, name string) bool {
+	return nodeUses(n.Pipe, name) || nodeUses(n.List, name) || nodeUses(n.ElseList, name)
+}
+
+// Execute renders m, ending the output with a newline if the template did
+// not. Errors name the entry they occurred on.
+func (t *Template) Execute(w io.Writer, m stat.CommonStat) error {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, m); err != nil {
+		return fmt.Errorf("error formatting %s: %w", m.GetAbsolutePath(), err)
+	}
+	if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (o TemplateOptions) funcs() template.FuncMap {
+	human := o.Size
+	if !human.Human {
+		human = size.HumanReadable
+	}
+	return template.FuncMap{
+		"humanSize": func(n int64) string {
+			return human.Format(n)
+		},
+		"modeString": func(m stat.CommonStat) string {
+			return m.GetStat().ModeString()
+		},
+		"timeFormat": func(format string, t stat.Time) string {
+			style, err := timefmt.ParseStyle(format)
+			if err != nil {
+				return timefmt.Strftime(t.Time, format)
+			}
+			return style.Format(t.Time, o.Now)
+		},
+		"color": func(m stat.CommonStat, text string) string {
+			if o.Colors == nil {
+				return text
+			}
+			return o.Colors.Paint(o.Colors.For(m, text), text)
+		},
+		"targets": func(m stat.CommonStat) []string {
+			if l, ok := m.(stat.StatLink); ok {
+				return l.Targets
+			}
+			return nil
+		},
+		"join": func(sep string, elems []string) string {
+			return strings.Join(elems, sep)
+		},
+	}
+}
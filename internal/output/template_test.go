@@ -0,0 +1,105 @@
+package output
+
+import (
+	"bytes"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sochoa/go-ls/internal/color"
+	"github.com/sochoa/go-ls/internal/stat"
+	"github.com/stretchr/testify/require"
+)
+
+func templateEntry() stat.Stat {
+	var s stat.Stat
+	s.SchemaVersion = stat.SchemaVersion
+	s.Type = stat.RegularFileType
+	s.Mode = syscall.S_IFREG | 0o644
+	s.SizeBytes = 4096
+	s.UserName = "alice"
+	s.BaseName = "notes.txt"
+	s.AbsolutePath = "/home/alice/notes.txt"
+	s.LastModifiedTime = stat.Time{Time: time.Date(2024, time.March, 14, 15, 9, 26, 0, time.UTC)}
+	s.Permissions.Octal = "644"
+	s.Permissions.Symbolic.Owner.Read = true
+	s.Permissions.Symbolic.Owner.Write = true
+	s.Permissions.Symbolic.Group.Read = true
+	s.Permissions.Symbolic.Other.Read = true
+	return s
+}
+
+func TestTemplateFields(t *testing.T) {
+	tmpl, err := NewTemplate("{{.Permissions.Octal}} {{.UserName}} {{.BaseName}}", TemplateOptions{})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, tmpl.Execute(&buf, templateEntry()))
+	require.Equal(t, "644 alice notes.txt\n", buf.String())
+}
+
+func TestTemplateHelpers(t *testing.T) {
+	opts := TemplateOptions{
+		Colors: color.Parse("*.txt=01;33"),
+		Now:    time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC),
+	}
+	tmpl, err := NewTemplate(
+		`{{modeString .}} {{humanSize .SizeBytes}} {{timeFormat "long-iso" .LastModifiedTime}} `+
+			`{{.LastModifiedTime | timeFormat "%Y"}} {{color . .BaseName}}{{"\n"}}`, opts)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, tmpl.Execute(&buf, templateEntry()))
+	require.Equal(t, "-rw-r--r-- 4.0K 2024-03-14 15:09 2024 \x1b[01;33mnotes.txt\x1b[0m\n", buf.String())
+}
+
+func TestTemplateTargets(t *testing.T) {
+	tmpl, err := NewTemplate(`{{.BaseName}} {{targets . | join " -> "}}`, TemplateOptions{})
+	require.NoError(t, err)
+
+	link := stat.StatLink{Stat: templateEntry(), Targets: []string{"/a", "/b"}}
+	var buf bytes.Buffer
+	require.NoError(t, tmpl.Execute(&buf, link))
+	require.NoError(t, tmpl.Execute(&buf, templateEntry()))
+	require.Equal(t, "notes.txt /a -> /b\nnotes.txt \n", buf.String())
+}
+
+func TestTemplateUses(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{`{{.BaseName}}`, false},
+		{`{{.Hashes.sha256}} {{.BaseName}}`, true},
+		{`{{with .ELF}}{{.Type}}{{end}} {{index .Hashes "md5"}}`, true},
+		{`{{if .Targets}}{{else}}{{$.Hashes}}{{end}}`, true},
+		{`{{range $k, $v := .Hashes}}{{$k}}{{end}}`, true},
+		{`{{define "sums"}}{{.Hashes}}{{end}}{{template "sums" .}}`, true},
+		{`{{index . "Hashes"}}`, true},
+		{`{{"hashes"}}`, false},
+	}
+	for _, tt := range tests {
+		tmpl, err := NewTemplate(tt.text, TemplateOptions{})
+		require.NoError(t, err)
+		require.Equal(t, tt.want, tmpl.Uses("hashes"), tt.text)
+	}
+
+	tmpl, err := NewTemplate(`{{.ELF.Type}}`, TemplateOptions{})
+	require.NoError(t, err)
+	require.True(t, tmpl.Uses("elf"))
+	require.False(t, tmpl.Uses("missing"))
+}
+
+func TestTemplateErrors(t *testing.T) {
+	_, err := NewTemplate("{{.BaseName", TemplateOptions{})
+	require.Error(t, err)
+
+	tmpl, err := NewTemplate("{{.Targets}}", TemplateOptions{})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, templateEntry())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "/home/alice/notes.txt")
+	require.Empty(t, buf.String())
+}
@@ -0,0 +1,103 @@
+package output
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sochoa/go-ls/internal/layout"
+	"github.com/sochoa/go-ls/internal/stat"
+)
+
+// TreeOptions controls how Tree draws entries.
+type TreeOptions struct {
+	// ASCII draws the connectors with plain ASCII instead of box-drawing
+	// characters, for terminals without UTF-8.
+	ASCII bool
+	// Name decorates the name shown for an entry, e.g. with colour or a
+	// link target. Nil shows names as they are.
+	Name func(m stat.CommonStat, name string) string
+	// Columns returns metadata shown in front of the connectors, as with
+	// ls -l. Nil shows names alone.
+	Columns func(m stat.CommonStat) []string
+	// Aligns aligns the Columns cells.
+	Aligns []layout.Align
+}
+
+// treeGlyphs are the branch, last branch, continuation and blank prefixes.
+var (
+	unicodeGlyphs = [4]string{"├── ", "└── ", "│   ", "    "}
+	asciiGlyphs   = [4]string{"|-- ", "`-- ", "|   ", "    "}
+)
+
+// Tree writes roots and their descendants the way tree(1) does, followed by
+// a count of the directories and files beneath the roots.
+func Tree(w io.Writer, roots []Node, opts TreeOptions) error {
+	glyphs := unicodeGlyphs
+	if opts.ASCII {
+		glyphs = asciiGlyphs
+	}
+
+	var (
+		rows        [][]string
+		dirs, files int
+	)
+	var add func(n Node, prefix, connector string, root bool)
+	add = func(n Node, prefix, connector string, root bool) {
+		name := n.Name
+		if name == "" {
+			name = n.Entry.GetStat().BaseName
+		}
+		if opts.Name != nil {
+			name = opts.Name(n.Entry, name)
+		}
+		var row []string
+		if opts.Columns != nil {
+			row = opts.Columns(n.Entry)
+		}
+		rows = append(rows, append(row, prefix+connector+name))
+
+		if !root {
+			if n.Entry.GetType() == stat.DirectoryFileType {
+				dirs++
+			} else {
+				files++
+			}
+			if connector == glyphs[1] {
+				prefix += glyphs[3]
+			} else {
+				prefix += glyphs[2]
+			}
+		}
+		for i, child := range n.Children {
+			connector := glyphs[0]
+			if i == len(n.Children)-1 {
+				connector = glyphs[1]
+			}
+			add(child, prefix, connector, false)
+		}
+	}
+	for _, root := range roots {
+		add(root, "", "", true)
+	}
+
+	if opts.Columns != nil {
+		if err := layout.WriteTable(w, rows, opts.Aligns); err != nil {
+			return err
+		}
+	} else {
+		for _, row := range rows {
+			if _, err := fmt.Fprintln(w, row[0]); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := fmt.Fprintf(w, "\n%s, %s\n", plural(dirs, "directory", "directories"), plural(files, "file", "files"))
+	return err
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, one)
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
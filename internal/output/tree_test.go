@@ -0,0 +1,72 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sochoa/go-ls/internal/layout"
+	"github.com/sochoa/go-ls/internal/stat"
+	"github.com/stretchr/testify/require"
+)
+
+func treeNodes() []Node {
+	entry := func(name, typ string) stat.Stat {
+		s := templateEntry()
+		s.BaseName = name
+		s.Type = typ
+		return s
+	}
+	return []Node{{
+		Name:  ".",
+		Entry: entry("home", stat.DirectoryFileType),
+		Children: []Node{
+			{Entry: entry("docs", stat.DirectoryFileType), Children: []Node{
+				{Entry: entry("a.md", stat.RegularFileType)},
+				{Entry: entry("b.md", stat.RegularFileType)},
+			}},
+			{Entry: entry("empty", stat.DirectoryFileType), Children: []Node{}},
+			{Entry: entry("notes.txt", stat.RegularFileType)},
+		},
+	}}
+}
+
+func TestTree(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Tree(&buf, treeNodes(), TreeOptions{}))
+	require.Equal(t, `.
+├── docs
+│   ├── a.md
+│   └── b.md
+├── empty
+└── notes.txt
+
+2 directories, 3 files
+`, buf.String())
+}
+
+func TestTreeASCII(t *testing.T) {
+	var buf bytes.Buffer
+	opts := TreeOptions{
+		ASCII: true,
+		Name:  func(m stat.CommonStat, name string) string { return "<" + name + ">" },
+	}
+	require.NoError(t, Tree(&buf, treeNodes()[0].Children[:1], opts))
+	require.Equal(t, "<docs>\n|-- <a.md>\n`-- <b.md>\n\n0 directories, 2 files\n", buf.String())
+}
+
+func TestTreeColumns(t *testing.T) {
+	var buf bytes.Buffer
+	opts := TreeOptions{
+		Columns: func(m stat.CommonStat) []string {
+			return []string{m.GetType(), m.GetStat().UserName}
+		},
+		Aligns: []layout.Align{layout.Right, layout.Left},
+	}
+	require.NoError(t, Tree(&buf, treeNodes()[0].Children[:1], opts))
+	require.Equal(t, `directory alice docs
+     file alice ├── a.md
+     file alice └── b.md
+
+0 directories, 2 files
+`, buf.String())
+}
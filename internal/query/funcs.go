@@ -0,0 +1,125 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// call parses the arguments of the function named by tok, whose opening
+// parenthesis has been read, and type checks the call.
+func (p *parser) call(tok token) (expr, error) {
+	var args []expr
+	if _, ok := p.accept(")"); !ok {
+		for {
+			arg, err := p.or()
+			if err != nil {
+				return expr{}, err
+			}
+			args = append(args, arg)
+			if _, ok := p.accept(","); !ok {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return expr{}, err
+		}
+	}
+
+	kinds := make([]kind, len(args))
+	for i, a := range args {
+		kinds[i] = a.kind
+	}
+	is := func(want ...kind) bool {
+		if len(kinds) != len(want) {
+			return false
+		}
+		for i := range want {
+			if kinds[i] != want[i] {
+				return false
+			}
+		}
+		return true
+	}
+	unary := func(k kind, f func(a value) value) (expr, error) {
+		return expr{kind: k, eval: func(v reflect.Value) value { return f(args[0].eval(v)) }}, nil
+	}
+	binary := func(f func(a, b value) bool) (expr, error) {
+		return expr{kind: kindBool, eval: func(v reflect.Value) value {
+			return value{b: f(args[0].eval(v), args[1].eval(v))}
+		}}, nil
+	}
+
+	switch tok.text {
+	case "now":
+		if is() {
+			return constant(kindTime, value{t: p.now}), nil
+		}
+	case "duration":
+		if is(kindString) && args[0].constant != nil {
+			d, err := parseDuration(*args[0].constant)
+			if err != nil {
+				return expr{}, errorAt(tok.pos, "invalid duration %q", *args[0].constant)
+			}
+			return constant(kindDuration, value{i: int64(d)}), nil
+		}
+	case "timestamp":
+		if is(kindString) && args[0].constant != nil {
+			s := *args[0].constant
+			for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+				if t, err := time.ParseInLocation(layout, s, p.now.Location()); err == nil {
+					return constant(kindTime, value{t: t}), nil
+				}
+			}
+			return expr{}, errorAt(tok.pos, "invalid timestamp %q, expected RFC 3339 or YYYY-MM-DD", s)
+		}
+	case "contains":
+		switch {
+		case is(kindString, kindString):
+			return binary(func(a, b value) bool { return strings.Contains(a.s, b.s) })
+		case is(kindList, kindString):
+			return binary(func(a, b value) bool {
+				for _, s := range a.l {
+					if s == b.s {
+						return true
+					}
+				}
+				return false
+			})
+		}
+	case "startsWith":
+		if is(kindString, kindString) {
+			return binary(func(a, b value) bool { return strings.HasPrefix(a.s, b.s) })
+		}
+	case "endsWith":
+		if is(kindString, kindString) {
+			return binary(func(a, b value) bool { return strings.HasSuffix(a.s, b.s) })
+		}
+	case "lower":
+		if is(kindString) {
+			return unary(kindString, func(a value) value { return value{s: strings.ToLower(a.s)} })
+		}
+	case "upper":
+		if is(kindString) {
+			return unary(kindString, func(a value) value { return value{s: strings.ToUpper(a.s)} })
+		}
+	case "len":
+		switch {
+		case is(kindString):
+			return unary(kindInt, func(a value) value { return value{i: int64(len(a.s))} })
+		case is(kindList):
+			return unary(kindInt, func(a value) value { return value{i: int64(len(a.l))} })
+		}
+	case "matches":
+		if is(kindString, kindString) {
+			re, err := compileRegexp(args[1], tok.pos)
+			if err != nil {
+				return expr{}, err
+			}
+			return unary(kindBool, func(a value) value { return value{b: re.MatchString(a.s)} })
+		}
+	default:
+		return expr{}, errorAt(tok.pos, "unknown function %s", tok.text)
+	}
+	return expr{}, mismatch(tok.pos, tok.text+"()", kinds...)
+}
@@ -0,0 +1,198 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokInt
+	tokFloat
+	tokDuration
+	tokString
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int // byte offset, for error messages
+	i    int64
+	f    float64
+	d    time.Duration
+}
+
+// operators are the multi and single character operators, longest first so
+// that e.g. <= is not read as <.
+var operators = []string{
+	"||", "&&", "==", "!=", "<=", ">=", "=~", "!~", "<<", ">>",
+	"!", "<", ">", "+", "-", "*", "/", "%", "&", "|", "(", ")", ",", ".",
+}
+
+// lex splits src into tokens.
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for pos := 0; pos < len(src); {
+		r := rune(src[pos])
+		switch {
+		case unicode.IsSpace(r):
+			pos++
+		case r == '_' || unicode.IsLetter(r):
+			end := pos
+			for end < len(src) && (src[end] == '_' || isAlnum(src[end])) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[pos:end], pos: pos})
+			pos = end
+		case unicode.IsDigit(r):
+			end := numberEnd(src, pos)
+			tok, err := number(src[pos:end], pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			pos = end
+		case r == '"' || r == '\'':
+			s, end, err := quoted(src, pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokString, text: s, pos: pos})
+			pos = end
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(src[pos:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, errorAt(pos, "unexpected %q", r)
+			}
+			tokens = append(tokens, token{kind: tokOp, text: op, pos: pos})
+			pos += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+func isAlnum(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// numberEnd returns the offset just past the number starting at src[pos]:
+// a run of letters, digits and underscores, with a decimal fraction and a
+// signed exponent when the run is decimal.
+func numberEnd(src string, pos int) int {
+	run := func(end int) int {
+		for end < len(src) && (src[end] == '_' || isAlnum(src[end])) {
+			end++
+		}
+		return end
+	}
+	end := run(pos)
+	if strings.ContainsAny(src[pos:end], "xXbBoO") {
+		return end
+	}
+	if end+1 < len(src) && src[end] == '.' && isDigit(src[end+1]) {
+		end = run(end + 1)
+	}
+	if last := src[end-1]; (last == 'e' || last == 'E') && end+1 < len(src) &&
+		(src[end] == '+' || src[end] == '-') && isDigit(src[end+1]) {
+		end = run(end + 1)
+	}
+	return end
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+// number reads an integer in any Go notation, a decimal float such as 0.5
+// or 1e-3, or a duration such as 90s, 1h30m, 7d or 2w.
+func number(text string, pos int) (token, error) {
+	if i, err := strconv.ParseInt(text, 0, 64); err == nil {
+		return token{kind: tokInt, text: text, pos: pos, i: i}, nil
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil && !strings.ContainsAny(text, "xXpP_") {
+		return token{kind: tokFloat, text: text, pos: pos, f: f}, nil
+	}
+	if d, err := parseDuration(text); err == nil {
+		return token{kind: tokDuration, text: text, pos: pos, d: d}, nil
+	}
+	return token{}, errorAt(pos, "invalid number %q", text)
+}
+
+// durationUnits extend time.ParseDuration's units with days and weeks.
+var durationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  24 * time.Hour,
+	"w":  7 * 24 * time.Hour,
+}
+
+// parseDuration parses a sequence of integers with units, e.g. 1d12h.
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+	var total time.Duration
+	for s != "" {
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		j := i
+		for j < len(s) && (s[j] < '0' || s[j] > '9') {
+			j++
+		}
+		n, err := strconv.ParseInt(s[:i], 10, 64)
+		unit, ok := durationUnits[s[i:j]]
+		if err != nil || !ok {
+			return 0, fmt.Errorf("invalid duration")
+		}
+		total += time.Duration(n) * unit
+		s = s[j:]
+	}
+	return total, nil
+}
+
+// quoted reads the string literal starting at src[pos], returning its value
+// and the offset just past the closing quote. Double quoted strings accept
+// the escapes of Go interpreted strings; single quoted strings are raw apart
+// from \', which suits regular expressions.
+func quoted(src string, pos int) (string, int, error) {
+	q := src[pos]
+	for end := pos + 1; end < len(src); end++ {
+		switch src[end] {
+		case '\\':
+			end++
+		case q:
+			body := src[pos+1 : end]
+			if q == '\'' {
+				return strings.ReplaceAll(body, `\'`, `'`), end + 1, nil
+			}
+			s, err := strconv.Unquote(`"` + body + `"`)
+			if err != nil {
+				return "", 0, errorAt(pos, "invalid string %s", src[pos:end+1])
+			}
+			return s, end + 1, nil
+		}
+	}
+	return "", 0, errorAt(pos, "unterminated string")
+}
+
+// errorAt reports a problem at a byte offset, counted from 1 for humans.
+func errorAt(pos int, format string, args ...any) error {
+	return fmt.Errorf("invalid where expression at column %d: %s", pos+1, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,328 @@
+package query
+
+import (
+	"cmp"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+type parser struct {
+	tokens []token
+	pos    int
+	now    time.Time
+	// fields are the lower-cased paths of the fields read.
+	fields []string
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept consumes the next token if it is one of the operators ops.
+func (p *parser) accept(ops ...string) (token, bool) {
+	tok := p.peek()
+	if tok.kind == tokOp && slices.Contains(ops, tok.text) {
+		return p.next(), true
+	}
+	return tok, false
+}
+
+func (p *parser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		tok := p.peek()
+		if tok.kind == tokEOF {
+			return errorAt(tok.pos, "expected %q at end of expression", op)
+		}
+		return errorAt(tok.pos, "expected %q, found %q", op, tok.text)
+	}
+	return nil
+}
+
+// binaryLevel parses one level of left-associative binary operators.
+func (p *parser) binaryLevel(operand func() (expr, error), ops ...string) (expr, error) {
+	left, err := operand()
+	if err != nil {
+		return expr{}, err
+	}
+	for {
+		op, ok := p.accept(ops...)
+		if !ok {
+			return left, nil
+		}
+		right, err := operand()
+		if err != nil {
+			return expr{}, err
+		}
+		if left, err = binary(op, left, right); err != nil {
+			return expr{}, err
+		}
+	}
+}
+
+func (p *parser) or() (expr, error) { return p.binaryLevel(p.and, "||") }
+
+func (p *parser) and() (expr, error) { return p.binaryLevel(p.comparison, "&&") }
+
+func (p *parser) comparison() (expr, error) {
+	return p.binaryLevel(p.additive, "==", "!=", "<", "<=", ">", ">=", "=~", "!~")
+}
+
+func (p *parser) additive() (expr, error) { return p.binaryLevel(p.multiplicative, "+", "-", "|") }
+
+func (p *parser) multiplicative() (expr, error) {
+	return p.binaryLevel(p.unary, "*", "/", "%", "<<", ">>", "&")
+}
+
+func (p *parser) unary() (expr, error) {
+	op, ok := p.accept("!", "-")
+	if !ok {
+		return p.primary()
+	}
+	operand, err := p.unary()
+	if err != nil {
+		return expr{}, err
+	}
+	switch {
+	case op.text == "!" && operand.kind == kindBool:
+		return expr{kind: kindBool, eval: func(v reflect.Value) value {
+			return value{b: !operand.eval(v).b}
+		}}, nil
+	case op.text == "-" && (operand.kind == kindInt || operand.kind == kindDuration):
+		return expr{kind: operand.kind, eval: func(v reflect.Value) value {
+			return value{i: -operand.eval(v).i}
+		}}, nil
+	case op.text == "-" && operand.kind == kindFloat:
+		return expr{kind: kindFloat, eval: func(v reflect.Value) value {
+			return value{f: -operand.eval(v).f}
+		}}, nil
+	}
+	return expr{}, mismatch(op.pos, op.text, operand.kind)
+}
+
+func (p *parser) primary() (expr, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokInt:
+		return constant(kindInt, value{i: tok.i}), nil
+	case tokFloat:
+		return constant(kindFloat, value{f: tok.f}), nil
+	case tokDuration:
+		return constant(kindDuration, value{i: int64(tok.d)}), nil
+	case tokString:
+		e := constant(kindString, value{s: tok.text})
+		e.constant = &tok.text
+		return e, nil
+	case tokOp:
+		if tok.text == "(" {
+			inner, err := p.or()
+			if err != nil {
+				return expr{}, err
+			}
+			return inner, p.expect(")")
+		}
+	case tokIdent:
+		switch tok.text {
+		case "true", "false":
+			return constant(kindBool, value{b: tok.text == "true"}), nil
+		}
+		if _, ok := p.accept("("); ok {
+			return p.call(tok)
+		}
+		path := tok.text
+		for {
+			if _, ok := p.accept("."); !ok {
+				break
+			}
+			part := p.next()
+			if part.kind != tokIdent {
+				return expr{}, errorAt(part.pos, "expected a field name after %s.", path)
+			}
+			path += "." + part.text
+		}
+		p.fields = append(p.fields, strings.ToLower(path))
+		return fieldExpr(path, tok.pos)
+	case tokEOF:
+		return expr{}, errorAt(tok.pos, "unexpected end of expression")
+	}
+	return expr{}, errorAt(tok.pos, "unexpected %q", tok.text)
+}
+
+func constant(k kind, v value) expr {
+	return expr{kind: k, eval: func(reflect.Value) value { return v }}
+}
+
+// toFloat converts an int expression to a float one.
+func toFloat(e expr) expr {
+	return expr{kind: kindFloat, eval: func(v reflect.Value) value {
+		return value{f: float64(e.eval(v).i)}
+	}}
+}
+
+// binary type checks and builds a binary operation.
+func binary(op token, l, r expr) (expr, error) {
+	switch {
+	case l.kind == kindInt && r.kind == kindFloat:
+		l = toFloat(l)
+	case l.kind == kindFloat && r.kind == kindInt:
+		r = toFloat(r)
+	}
+	lk, rk := l.kind, r.kind
+	same := lk == rk
+	build := func(k kind, f func(a, b value) value) (expr, error) {
+		return expr{kind: k, eval: func(v reflect.Value) value {
+			return f(l.eval(v), r.eval(v))
+		}}, nil
+	}
+	boolean := func(f func(a, b value) bool) (expr, error) {
+		return build(kindBool, func(a, b value) value { return value{b: f(a, b)} })
+	}
+	integer := func(f func(a, b int64) int64) (expr, error) {
+		return build(lk, func(a, b value) value { return value{i: f(a.i, b.i)} })
+	}
+	float := func(f func(a, b float64) float64) (expr, error) {
+		return build(kindFloat, func(a, b value) value { return value{f: f(a.f, b.f)} })
+	}
+
+	switch op.text {
+	case "||":
+		if same && lk == kindBool {
+			return expr{kind: kindBool, eval: func(v reflect.Value) value {
+				return value{b: l.eval(v).b || r.eval(v).b}
+			}}, nil
+		}
+	case "&&":
+		if same && lk == kindBool {
+			return expr{kind: kindBool, eval: func(v reflect.Value) value {
+				return value{b: l.eval(v).b && r.eval(v).b}
+			}}, nil
+		}
+	case "==", "!=":
+		if same && lk != kindList {
+			eq := func(a, b value) bool { return compare(lk, a, b) == 0 }
+			if op.text == "==" {
+				return boolean(eq)
+			}
+			return boolean(func(a, b value) bool { return !eq(a, b) })
+		}
+	case "<", "<=", ">", ">=":
+		if same && lk != kindList && lk != kindBool {
+			test := map[string]func(int) bool{
+				"<":  func(c int) bool { return c < 0 },
+				"<=": func(c int) bool { return c <= 0 },
+				">":  func(c int) bool { return c > 0 },
+				">=": func(c int) bool { return c >= 0 },
+			}[op.text]
+			return boolean(func(a, b value) bool { return test(compare(lk, a, b)) })
+		}
+	case "=~", "!~":
+		if same && lk == kindString {
+			re, err := compileRegexp(r, op.pos)
+			if err != nil {
+				return expr{}, err
+			}
+			want := op.text == "=~"
+			return expr{kind: kindBool, eval: func(v reflect.Value) value {
+				return value{b: re.MatchString(l.eval(v).s) == want}
+			}}, nil
+		}
+	case "+":
+		switch {
+		case same && (lk == kindInt || lk == kindDuration):
+			return integer(func(a, b int64) int64 { return a + b })
+		case same && lk == kindFloat:
+			return float(func(a, b float64) float64 { return a + b })
+		case same && lk == kindString:
+			return build(kindString, func(a, b value) value { return value{s: a.s + b.s} })
+		case lk == kindTime && rk == kindDuration:
+			return build(kindTime, func(a, b value) value { return value{t: a.t.Add(time.Duration(b.i))} })
+		case lk == kindDuration && rk == kindTime:
+			return build(kindTime, func(a, b value) value { return value{t: b.t.Add(time.Duration(a.i))} })
+		}
+	case "-":
+		switch {
+		case same && (lk == kindInt || lk == kindDuration):
+			return integer(func(a, b int64) int64 { return a - b })
+		case same && lk == kindFloat:
+			return float(func(a, b float64) float64 { return a - b })
+		case same && lk == kindTime:
+			return build(kindDuration, func(a, b value) value { return value{i: int64(a.t.Sub(b.t))} })
+		case lk == kindTime && rk == kindDuration:
+			return build(kindTime, func(a, b value) value { return value{t: a.t.Add(-time.Duration(b.i))} })
+		}
+	case "*":
+		switch {
+		case same && lk == kindInt:
+			return integer(func(a, b int64) int64 { return a * b })
+		case same && lk == kindFloat:
+			return float(func(a, b float64) float64 { return a * b })
+		case lk == kindDuration && rk == kindInt:
+			return integer(func(a, b int64) int64 { return a * b })
+		case lk == kindInt && rk == kindDuration:
+			return build(kindDuration, func(a, b value) value { return value{i: a.i * b.i} })
+		}
+	case "/", "%":
+		if same && lk == kindFloat && op.text == "/" {
+			return float(func(a, b float64) float64 {
+				if b == 0 {
+					return 0
+				}
+				return a / b
+			})
+		}
+		if (same && lk == kindInt) || (lk == kindDuration && rk == kindInt) {
+			div := op.text == "/"
+			// Dividing by zero gives 0 rather than failing the listing.
+			return integer(func(a, b int64) int64 {
+				switch {
+				case b == 0:
+					return 0
+				case div:
+					return a / b
+				}
+				return a % b
+			})
+		}
+	case "<<", ">>", "&", "|":
+		if same && lk == kindInt {
+			return integer(map[string]func(a, b int64) int64{
+				"<<": func(a, b int64) int64 { return a << uint64(b&63) },
+				">>": func(a, b int64) int64 { return a >> uint64(b&63) },
+				"&":  func(a, b int64) int64 { return a & b },
+				"|":  func(a, b int64) int64 { return a | b },
+			}[op.text])
+		}
+	}
+	return expr{}, mismatch(op.pos, op.text, lk, rk)
+}
+
+// compare orders two values of kind k.
+func compare(k kind, a, b value) int {
+	switch k {
+	case kindString:
+		return strings.Compare(a.s, b.s)
+	case kindTime:
+		return a.t.Compare(b.t)
+	case kindFloat:
+		return cmp.Compare(a.f, b.f)
+	case kindBool:
+		if a.b == b.b {
+			return 0
+		}
+		return 1
+	}
+	switch {
+	case a.i < b.i:
+		return -1
+	case a.i > b.i:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,276 @@
+package query
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sochoa/go-ls/internal/stat"
+)
+
+// kind is the static type of an expression.
+type kind int
+
+const (
+	kindInt kind = iota
+	kindFloat
+	kindString
+	kindBool
+	kindTime
+	kindDuration
+	kindList
+)
+
+func (k kind) String() string {
+	return [...]string{"int", "float", "string", "bool", "time", "duration", "list"}[k]
+}
+
+// value holds the result of evaluating an expression; which field is set
+// depends on its kind. Durations are kept in i as nanoseconds.
+type value struct {
+	i int64
+	f float64
+	s string
+	b bool
+	t time.Time
+	l []string
+}
+
+// expr is a type checked expression, compiled to a closure over the entry.
+type expr struct {
+	kind kind
+	eval func(v reflect.Value) value
+	// constant is set for string literals, so regular expressions can be
+	// compiled once.
+	constant *string
+}
+
+// Query is a compiled --where expression.
+type Query struct {
+	root   expr
+	fields []string
+}
+
+// Compile parses and type checks src against the fields of the JSON output,
+// reporting unknown fields, type mismatches and bad regular expressions
+// before any entry is read.
+//
+// Fields are written as in the JSON output, with dots for nesting, e.g.
+// permissions.symbolic.other.Write; names are matched case-insensitively.
+// The language has int, float, string, bool, time, duration and list values;
+// floats are written like 0.5 or 1e-3, and ints mixed with floats are
+// converted to floats. Durations are written like 90s, 1h30m or 7d.
+// Operators, loosest first:
+//
+//	||
+//	&&
+//	== != < <= > >= =~ !~
+//	+ - |
+//	* / % << >> &
+//	! -
+//
+// Functions: now(), duration(s), timestamp(s), contains(s|list, sub),
+// startsWith(s, prefix), endsWith(s, suffix), lower(s), upper(s), len(s|list)
+// and matches(s, re).
+func Compile(src string) (*Query, error) {
+	return CompileWithDeps(src, time.Now())
+}
+
+// CompileWithDeps is Compile with now() fixed to now.
+func CompileWithDeps(src string, now time.Time) (*Query, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, now: now}
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, errorAt(tok.pos, "unexpected %q", tok.text)
+	}
+	if root.kind != kindBool {
+		return nil, errorAt(0, "expression is %s, not bool", root.kind)
+	}
+	return &Query{root: root, fields: p.fields}, nil
+}
+
+// Uses reports whether the query reads field, or a field beneath it when
+// field is an object or a map, so that callers can gather what it needs.
+func (q *Query) Uses(field string) bool {
+	if q == nil {
+		return false
+	}
+	field = strings.ToLower(field)
+	for _, f := range q.fields {
+		if f == field || strings.HasPrefix(f, field+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether m satisfies the query.
+func (q *Query) Match(m stat.CommonStat) bool {
+	l, ok := m.(stat.StatLink)
+	if !ok {
+		l = stat.StatLink{Stat: m.GetStat()}
+	}
+	return q.root.eval(reflect.ValueOf(l)).b
+}
+
+// field is a leaf of the JSON field tree of stat.StatLink.
+type field struct {
+	name  string
+	index []int
+	kind  kind
+	isMap bool
+}
+
+var (
+	timeType  = reflect.TypeOf(stat.Time{})
+	allFields = fieldTree()
+)
+
+// fieldTree maps the lower-cased dotted JSON path of every leaf field of
+// stat.StatLink to the field.
+func fieldTree() map[string]field {
+	fields := map[string]field{}
+	for _, f := range stat.Leaves() {
+		if f.Type.Kind() == reflect.Map && f.Type.Key().Kind() == reflect.String && f.Type.Elem().Kind() == reflect.String {
+			// Keys are not known in advance; see mapFieldExpr.
+			fields[strings.ToLower(f.Name)] = field{name: f.Name, index: f.Index, kind: kindString, isMap: true}
+			continue
+		}
+		k, ok := kindOf(f.Type)
+		if !ok {
+			continue
+		}
+		fields[strings.ToLower(f.Name)] = field{name: f.Name, index: f.Index, kind: k}
+	}
+	return fields
+}
+
+// get reads the field from v, a stat.StatLink, as the zero value when it is
+// inside an absent optional object.
+func (f field) get(v reflect.Value) reflect.Value {
+	fv, err := v.FieldByIndexErr(f.index)
+	if err != nil {
+		return reflect.Zero(v.Type().FieldByIndex(f.index).Type)
+	}
+	return fv
+}
+
+func kindOf(t reflect.Type) (kind, bool) {
+	if t == timeType {
+		return kindTime, true
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return kindString, true
+	case reflect.Bool:
+		return kindBool, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return kindInt, true
+	case reflect.Float32, reflect.Float64:
+		return kindFloat, true
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.String {
+			return kindList, true
+		}
+	}
+	return 0, false
+}
+
+// fieldExpr returns the expression reading the field at path.
+func fieldExpr(path string, pos int) (expr, error) {
+	f, ok := allFields[strings.ToLower(path)]
+	if f.isMap {
+		return expr{}, errorAt(pos, "%s is a map; use %s.KEY", path, f.name)
+	}
+	if e, ok := mapFieldExpr(path); ok {
+		return e, nil
+	}
+	if !ok {
+		var prefixed []string
+		for name, f := range allFields {
+			if strings.HasPrefix(name, strings.ToLower(path)+".") {
+				prefixed = append(prefixed, f.name)
+			}
+		}
+		if len(prefixed) > 0 {
+			sort.Strings(prefixed)
+			return expr{}, errorAt(pos, "%s is an object; use one of %s", path, strings.Join(prefixed, ", "))
+		}
+		return expr{}, errorAt(pos, "unknown field %s", path)
+	}
+	get := f.get
+	e := expr{kind: f.kind}
+	switch f.kind {
+	case kindTime:
+		e.eval = func(v reflect.Value) value { return value{t: get(v).Interface().(stat.Time).Time} }
+	case kindString:
+		e.eval = func(v reflect.Value) value { return value{s: get(v).String()} }
+	case kindBool:
+		e.eval = func(v reflect.Value) value { return value{b: get(v).Bool()} }
+	case kindList:
+		e.eval = func(v reflect.Value) value { return value{l: get(v).Interface().([]string)} }
+	case kindInt:
+		e.eval = func(v reflect.Value) value {
+			fv := get(v)
+			if fv.CanInt() {
+				return value{i: fv.Int()}
+			}
+			return value{i: int64(fv.Uint())}
+		}
+	case kindFloat:
+		e.eval = func(v reflect.Value) value { return value{f: get(v).Float()} }
+	}
+	return e, nil
+}
+
+// mapFieldExpr returns the expression reading one key of a map field, such
+// as hashes.sha256, which is empty when the key is missing.
+func mapFieldExpr(path string) (expr, bool) {
+	for name, f := range allFields {
+		key, ok := strings.CutPrefix(strings.ToLower(path), name+".")
+		if !ok || !f.isMap {
+			continue
+		}
+		key = path[len(path)-len(key):]
+		return expr{kind: kindString, eval: func(v reflect.Value) value {
+			s := f.get(v).MapIndex(reflect.ValueOf(key))
+			if !s.IsValid() {
+				return value{}
+			}
+			return value{s: s.String()}
+		}}, true
+	}
+	return expr{}, false
+}
+
+// compileRegexp compiles the pattern of =~, !~ or matches(), which must be a
+// string literal so that it is compiled once.
+func compileRegexp(e expr, pos int) (*regexp.Regexp, error) {
+	if e.constant == nil {
+		return nil, errorAt(pos, "regular expression must be a string literal")
+	}
+	re, err := regexp.Compile(*e.constant)
+	if err != nil {
+		return nil, errorAt(pos, "invalid regular expression: %v", err)
+	}
+	return re, nil
+}
+
+// mismatch reports operands an operator does not accept.
+func mismatch(pos int, op string, kinds ...kind) error {
+	names := make([]string, len(kinds))
+	for i, k := range kinds {
+		names[i] = k.String()
+	}
+	return errorAt(pos, "%s is not defined on %s", op, strings.Join(names, " and "))
+}
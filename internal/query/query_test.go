@@ -0,0 +1,147 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sochoa/go-ls/internal/elfinfo"
+	"github.com/sochoa/go-ls/internal/stat"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+func entry(name, typ string, size int64, age time.Duration, otherWrite bool) stat.Stat {
+	var s stat.Stat
+	s.BaseName = name
+	s.AbsolutePath = "/src/" + name
+	s.Type = typ
+	s.SizeBytes = size
+	s.LastModifiedTime.Time = now.Add(-age)
+	s.UserName = "alice"
+	s.HardLinkReferenceCount = 1
+	s.Permissions.Octal = "0644"
+	s.Permissions.Symbolic.Owner.Read = true
+	s.Permissions.Symbolic.Other.Write = otherWrite
+	return s
+}
+
+var (
+	mainGo  = elfBinary(hashed(entry("main.go", stat.RegularFileType, 2048, time.Hour, false), "sha256", "9f86d081"))
+	bigLog  = sparse(entry("big.log", stat.RegularFileType, 3<<20, 10*24*time.Hour, true), 0.75)
+	vendor  = entry("vendor", stat.DirectoryFileType, 4096, 2*time.Hour, false)
+	link    = stat.StatLink{Stat: entry("latest", stat.SymbolicLinkFileType, 7, time.Minute, false), Targets: []string{"/src/big.log"}}
+	entries = []stat.CommonStat{mainGo, bigLog, vendor, link}
+)
+
+func hashed(s stat.Stat, algorithm, sum string) stat.Stat {
+	s.Hashes = map[string]string{algorithm: sum}
+	return s
+}
+
+func sparse(s stat.Stat, fraction float64) stat.Stat {
+	s.Sparse = fraction
+	return s
+}
+
+func elfBinary(s stat.Stat) stat.Stat {
+	s.ELF = &elfinfo.ELF{
+		Type:    "pie",
+		Linkage: "dynamic",
+		Needed:  []string{"libc.so.6"},
+		Go:      &elfinfo.GoBuild{Version: "go1.23.3", Settings: map[string]string{"CGO_ENABLED": "1"}},
+	}
+	return s
+}
+
+func matching(t *testing.T, src string) []string {
+	t.Helper()
+	q, err := CompileWithDeps(src, now)
+	require.NoError(t, err, src)
+	var names []string
+	for _, e := range entries {
+		if q.Match(e) {
+			names = append(names, e.GetStat().BaseName)
+		}
+	}
+	return names
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{"true", []string{"main.go", "big.log", "vendor", "latest"}},
+		{"size_bytes > 1<<20 && permissions.symbolic.other.write", []string{"big.log"}},
+		{"size_bytes >= 0x800 && size_bytes % 1024 == 0", []string{"main.go", "big.log", "vendor"}},
+		{`type == "directory" || basename =~ '\.go$'`, []string{"main.go", "vendor"}},
+		{`basename !~ "^[a-z]+\\."`, []string{"vendor", "latest"}},
+		{"!(type == 'file')", []string{"vendor", "latest"}},
+		{"now() - last_modified_time < 90m", []string{"main.go", "latest"}},
+		{"last_modified_time > now() - 7d", []string{"main.go", "vendor", "latest"}},
+		{"last_modified_time + 1w < now()", []string{"big.log"}},
+		{"last_modified_time < timestamp('2024-05-30')", []string{"big.log"}},
+		{"now() - last_modified_time > duration('1h30m') * 2 - 1h", []string{"big.log"}},
+		{"contains(targets, '/src/big.log')", []string{"latest"}},
+		{"len(targets) == 0 && contains(basename, 'o')", []string{"main.go", "big.log", "vendor"}},
+		{"startsWith(absolute_path, '/src/b') || endsWith(upper(basename), '.GO')", []string{"main.go", "big.log"}},
+		{"lower(USER_NAME) == 'alice' && Permissions.Octal == '0644'", []string{"main.go", "big.log", "vendor", "latest"}},
+		{"matches(basename, '^v') || len(basename) == 6 && -size_bytes > -10", []string{"vendor", "latest"}},
+		{"hashes.sha256 == '9f86d081' || startsWith(Hashes.SHA256, 'x')", []string{"main.go"}},
+		{"hashes.md5 == ''", []string{"main.go", "big.log", "vendor", "latest"}},
+		{"elf.type == 'pie' && elf.go.settings.CGO_ENABLED == '1'", []string{"main.go"}},
+		{"elf.linkage == '' && !elf.stripped && len(elf.needed) == 0", []string{"big.log", "vendor", "latest"}},
+		{"size_bytes / 0 == 0 && hard_link_reference_count | 2 == 3", []string{"main.go", "big.log", "vendor", "latest"}},
+		{"sparse >= 0", []string{"main.go", "big.log", "vendor", "latest"}},
+		{"sparse > 0.5 && sparse * size_bytes == 2.25 * (1<<20)", []string{"big.log"}},
+		{"1 - sparse == 0.25 || -sparse > -1e-3 && sparse / 0.0 == 0", []string{"main.go", "big.log", "vendor", "latest"}},
+		{"size_bytes > 1.5e6 && sparse != 0", []string{"big.log"}},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, matching(t, tt.src), tt.src)
+	}
+}
+
+func TestUses(t *testing.T) {
+	q, err := CompileWithDeps(`Kind == "image" || hashes.sha256 == "" || elf.go.version != ""`, now)
+	require.NoError(t, err)
+	require.True(t, q.Uses("kind"))
+	require.True(t, q.Uses("hashes"))
+	require.True(t, q.Uses("elf"))
+	require.False(t, q.Uses("mime_type"))
+	require.False(t, q.Uses("el"))
+
+	var none *Query
+	require.False(t, none.Uses("kind"))
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"size_bytes", "column 1: expression is int, not bool"},
+		{"size > 1", "column 1: unknown field size"},
+		{"permissions.symbolic.other", "column 1: permissions.symbolic.other is an object; use one of permissions.symbolic.other.Execute"},
+		{"size_bytes > '1'", "column 12: > is not defined on int and string"},
+		{"basename =~ '('", "column 10: invalid regular expression"},
+		{"basename =~ basename", "column 10: regular expression must be a string literal"},
+		{"last_modified_time > now() - 3q", "column 30: invalid number \"3q\""},
+		{"timestamp('yesterday') < now()", "column 1: invalid timestamp"},
+		{"duration(basename) > 1s", "column 1: duration() is not defined on string"},
+		{"shout(basename)", "column 1: unknown function shout"},
+		{"(true", "column 6: expected \")\" at end of expression"},
+		{"true true", "column 6: unexpected \"true\""},
+		{"basename == 'open", "column 13: unterminated string"},
+		{"size_bytes > 1 #", "column 16: unexpected '#'"},
+		{"hashes == ''", "column 1: hashes is a map; use hashes.KEY"},
+		{"permissions.", "column 13: expected a field name after permissions."},
+		{"sparse % 2 == 0", "column 8: % is not defined on float and float"},
+		{"sparse > '0'", "column 8: > is not defined on float and string"},
+	}
+	for _, tt := range tests {
+		_, err := CompileWithDeps(tt.src, now)
+		require.ErrorContains(t, err, tt.want, tt.src)
+	}
+}
@@ -0,0 +1,170 @@
+package schema
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/sochoa/go-ls/internal/stat"
+)
+
+// Published is the committed JSON Schema for one entry of the JSON output.
+// Tests keep it in step with Generate.
+//
+//go:embed entry.schema.json
+var Published []byte
+
+const (
+	draft = "https://json-schema.org/draft/2020-12/schema"
+	id    = "https://github.com/sochoa/go-ls/internal/schema/entry.schema.json"
+)
+
+// Schema is the subset of JSON Schema the generator emits. Fields are in
+// the order they are written. AdditionalProperties is false for structs and
+// the schema of every value for maps.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Const                string             `json:"const,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           *Properties        `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// Properties are an object's property schemas, kept in field order.
+type Properties struct {
+	names   []string
+	schemas map[string]*Schema
+}
+
+func (p *Properties) add(name string, s *Schema) {
+	if p.schemas == nil {
+		p.schemas = map[string]*Schema{}
+	}
+	p.names = append(p.names, name)
+	p.schemas[name] = s
+}
+
+// MarshalJSON writes the properties in the order they were added.
+func (p *Properties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range p.names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(p.schemas[name])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Generate derives the JSON Schema of an entry from the json tags of
+// stat.Stat and stat.StatLink, formatted the way Published is committed.
+func Generate() ([]byte, error) {
+	g := generator{defs: map[string]*Schema{}}
+	root := g.object(reflect.TypeOf(stat.StatLink{}))
+	// Only symbolic links carry these; every other entry is a plain Stat.
+	root.Required = slices.DeleteFunc(root.Required, func(name string) bool {
+		return name == "targets" || name == "dangling"
+	})
+	root.Schema = draft
+	root.ID = id
+	root.Title = "go-ls entry"
+	root.Description = "One entry of the go-ls JSON output. targets and dangling are present only for symbolic links."
+	root.Defs = g.defs
+
+	b, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	return append(b, '\n'), nil
+}
+
+type generator struct {
+	defs map[string]*Schema
+}
+
+// object returns the schema of struct type t, flattening embedded structs
+// the way encoding/json does.
+func (g generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: &Properties{}, AdditionalProperties: new(bool)}
+	g.fields(s, t)
+	return s
+}
+
+func (g generator) fields(s *Schema, t reflect.Type) {
+	for _, f := range stat.Fields(t) {
+		prop := g.value(f.Type)
+		if f.Name == "schema_version" {
+			prop = &Schema{Type: "string", Const: stat.SchemaVersion}
+		}
+		s.Properties.add(f.Name, prop)
+		if !f.OmitEmpty {
+			s.Required = append(s.Required, f.Name)
+		}
+	}
+}
+
+// value returns the schema of a field of type t. Named structs from other
+// packages become shared definitions.
+func (g generator) value(t reflect.Type) *Schema {
+	if t == reflect.TypeOf(stat.Time{}) {
+		return &Schema{
+			Description: "RFC 3339 date-time, or Unix seconds with --json-time epoch",
+			OneOf: []*Schema{
+				{Type: "string", Format: "date-time"},
+				{Type: "number"},
+			},
+		}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Minimum: new(int)}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.value(t.Elem())}
+	case reflect.Pointer:
+		return g.value(t.Elem())
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.value(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		if _, ok := g.defs[t.Name()]; !ok {
+			g.defs[t.Name()] = g.object(t)
+		}
+		return &Schema{Ref: "#/$defs/" + t.Name()}
+	}
+	return &Schema{}
+}
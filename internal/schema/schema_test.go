@@ -0,0 +1,53 @@
+package schema
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/sochoa/go-ls/internal/stat"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite entry.schema.json from the stat types")
+
+// TestPublishedSchema fails when stat.Stat or stat.StatLink change without
+// the committed schema being regenerated. Regenerate it with
+// go test ./internal/schema -update, and bump stat.SchemaVersion.
+func TestPublishedSchema(t *testing.T) {
+	generated, err := Generate()
+	require.NoError(t, err)
+	if *update {
+		require.NoError(t, os.WriteFile("entry.schema.json", generated, 0o644))
+		return
+	}
+	require.Equal(t, string(Published), string(generated),
+		"entry.schema.json is out of date; run go test ./internal/schema -update and bump stat.SchemaVersion")
+}
+
+func TestSchemaCoversJson(t *testing.T) {
+	var schema struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+		Required   []string                   `json:"required"`
+	}
+	require.NoError(t, json.Unmarshal(Published, &schema))
+
+	link := stat.StatLink{Stat: stat.Stat{SchemaVersion: stat.SchemaVersion, SizeHuman: "1K", RealPath: "/a"}}
+	b, err := json.Marshal(link)
+	require.NoError(t, err)
+	var entry map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(b, &entry))
+
+	for key := range entry {
+		require.Contains(t, schema.Properties, key)
+	}
+	for _, key := range schema.Required {
+		require.Contains(t, entry, key)
+	}
+	var version struct {
+		Const string `json:"const"`
+	}
+	require.NoError(t, json.Unmarshal(schema.Properties["schema_version"], &version))
+	require.Equal(t, stat.SchemaVersion, version.Const)
+}
@@ -0,0 +1,184 @@
+package size
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// BlockBytes is the unit of stat's st_blocks, independent of the file
+// system block size.
+const BlockBytes = 512
+
+// Unit describes how a byte count is shown, following the GNU ls -h, --si
+// and --block-size options.
+type Unit struct {
+	// Human scales to the largest power of Base that keeps the value
+	// readable and appends a suffix, as with -h and --si.
+	Human bool
+	// Base is 1024 for -h and binary block sizes, 1000 for --si and
+	// decimal ones.
+	Base int64
+	// BlockSize divides counts that are not human readable.
+	BlockSize int64
+	// Suffix is appended to block-scaled counts when the block size was
+	// given as a unit name, e.g. "K" for --block-size=K.
+	Suffix string
+	// Group inserts thousands separators, from a leading ' in the block size.
+	Group bool
+}
+
+// Bytes shows counts as plain bytes, the default for file sizes.
+var Bytes = Unit{Base: 1024, BlockSize: 1}
+
+// Kibibytes shows counts in 1024 byte blocks, the default for -s.
+var Kibibytes = Unit{Base: 1024, BlockSize: 1024}
+
+// HumanReadable is the unit for -h.
+var HumanReadable = Unit{Human: true, Base: 1024, BlockSize: 1}
+
+// SI is the unit for --si.
+var SI = Unit{Human: true, Base: 1000, BlockSize: 1}
+
+var prefixes = "KMGTPEZY"
+
+// ParseBlockSize parses a --block-size value: an optional ' for thousands
+// separators, an optional integer and an optional unit such as K, KB, KiB,
+// M or G. Units ending in B are powers of 1000, the rest powers of 1024.
+// "human-readable" and "si" are accepted as aliases for -h and --si.
+func ParseBlockSize(s string) (Unit, error) {
+	switch s {
+	case "human-readable":
+		return HumanReadable, nil
+	case "si":
+		return SI, nil
+	}
+
+	u := Unit{Base: 1024}
+	if strings.HasPrefix(s, "'") {
+		u.Group = true
+		s = s[1:]
+	}
+	digits := len(s) - len(strings.TrimLeft(s, "0123456789"))
+	number, unit := s[:digits], s[digits:]
+	if number == "" && unit == "" {
+		return Unit{}, fmt.Errorf("invalid block size %q", s)
+	}
+
+	multiplier := int64(1)
+	if number != "" {
+		n, err := strconv.ParseInt(number, 10, 64)
+		if err != nil || n <= 0 {
+			return Unit{}, fmt.Errorf("invalid block size %q", s)
+		}
+		multiplier = n
+	}
+
+	scale := int64(1)
+	if unit != "" {
+		base := int64(1024)
+		name := unit
+		switch {
+		case strings.HasSuffix(unit, "iB"):
+			name = strings.TrimSuffix(unit, "iB")
+		case strings.HasSuffix(unit, "B") && len(unit) == 2:
+			base = 1000
+			name = strings.TrimSuffix(unit, "B")
+		}
+		idx := strings.Index(prefixes, strings.ToUpper(name))
+		if len(name) != 1 || idx < 0 {
+			return Unit{}, fmt.Errorf("invalid block size %q", s)
+		}
+		for i := 0; i <= idx; i++ {
+			scale *= base
+		}
+		u.Base = base
+		if number == "" {
+			u.Suffix = unit
+		}
+	}
+	u.BlockSize = multiplier * scale
+	return u, nil
+}
+
+// Format renders n bytes in unit u. Scaled values are rounded up, as ls
+// does, so that a non-empty file never shows as zero.
+func (u Unit) Format(n int64) string {
+	if u.Human {
+		return u.human(n)
+	}
+	blockSize := u.BlockSize
+	if blockSize <= 0 {
+		blockSize = 1
+	}
+	count := n / blockSize
+	if n%blockSize != 0 {
+		count++
+	}
+	str := strconv.FormatInt(count, 10)
+	if u.Group {
+		str = group(str)
+	}
+	return str + u.Suffix
+}
+
+func (u Unit) human(n int64) string {
+	base := u.Base
+	if base == 0 {
+		base = 1024
+	}
+	if n < base {
+		return strconv.FormatInt(n, 10)
+	}
+
+	value := float64(n)
+	exp := -1
+	for value >= float64(base) && exp < len(prefixes)-1 {
+		value /= float64(base)
+		exp++
+	}
+	suffix := string(prefixes[exp])
+	if base == 1000 && suffix == "K" {
+		suffix = "k"
+	}
+
+	// One decimal place below 10, whole numbers above, rounding up. A
+	// value that rounds up to the base moves to the next prefix.
+	if value < 10 {
+		value = math.Ceil(value*10) / 10
+		if value < 10 {
+			return strconv.FormatFloat(value, 'f', 1, 64) + suffix
+		}
+	}
+	value = math.Ceil(value)
+	if value >= float64(base) && exp < len(prefixes)-1 {
+		return "1.0" + string(prefixes[exp+1])
+	}
+	return strconv.FormatFloat(value, 'f', 0, 64) + suffix
+}
+
+// group inserts a comma between every group of three digits.
+func group(digits string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+	var b strings.Builder
+	lead := len(digits) % 3
+	if lead > 0 {
+		b.WriteString(digits[:lead])
+	}
+	for i := lead; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
+
+// Allocated returns the bytes actually allocated to a file with numBlocks
+// st_blocks.
+func Allocated(numBlocks uint64) int64 {
+	return int64(numBlocks) * BlockBytes
+}
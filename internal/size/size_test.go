@@ -0,0 +1,77 @@
+package size
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatHuman(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		human string
+		si    string
+	}{
+		{0, "0", "0"},
+		{999, "999", "999"},
+		{1000, "1000", "1.0k"},
+		{1024, "1.0K", "1.1k"},
+		{1025, "1.1K", "1.1k"},
+		{4096, "4.0K", "4.1k"},
+		{10 * 1024, "10K", "11k"},
+		{1023 * 1024, "1023K", "1.1M"},
+		{1024*1024 - 1, "1.0M", "1.1M"},
+		{5 * 1024 * 1024 * 1024, "5.0G", "5.4G"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.human, HumanReadable.Format(tt.bytes), "-h %d", tt.bytes)
+		assert.Equal(t, tt.si, SI.Format(tt.bytes), "--si %d", tt.bytes)
+	}
+}
+
+func TestFormatBlocks(t *testing.T) {
+	assert.Equal(t, "12345", Bytes.Format(12345))
+	assert.Equal(t, "13", Kibibytes.Format(12345))
+	assert.Equal(t, "0", Kibibytes.Format(0))
+}
+
+func TestParseBlockSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		bytes    int64
+		expected string
+	}{
+		{"K", 12345, "13K"},
+		{"KiB", 12345, "13KiB"},
+		{"KB", 12345, "13KB"},
+		{"M", 3 * 1024 * 1024, "3M"},
+		{"MB", 3 * 1000 * 1000, "3MB"},
+		{"G", 1, "1G"},
+		{"1", 1234567, "1234567"},
+		{"'1", 1234567, "1,234,567"},
+		{"'1", 123, "123"},
+		{"4K", 16 * 1024, "4"},
+		{"'K", 2000 * 1024, "2,000K"},
+		{"human-readable", 4096, "4.0K"},
+		{"si", 4096, "4.1k"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			u, err := ParseBlockSize(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, u.Format(tt.bytes))
+		})
+	}
+}
+
+func TestParseBlockSizeInvalid(t *testing.T) {
+	for _, input := range []string{"", "'", "0", "X", "KX", "-1", "KiBB"} {
+		_, err := ParseBlockSize(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestAllocated(t *testing.T) {
+	assert.Equal(t, int64(4096), Allocated(8))
+}
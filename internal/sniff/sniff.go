@@ -0,0 +1,333 @@
+// Package sniff identifies the content of files from their first bytes,
+// with a built-in table of magic numbers in the manner of file(1).
+package sniff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// Kinds group MIME types into the broad classes used for filtering.
+const (
+	Executable = "executable"
+	Script     = "script"
+	Image      = "image"
+	Audio      = "audio"
+	Video      = "video"
+	Archive    = "archive"
+	Document   = "document"
+	Text       = "text"
+	Binary     = "binary"
+	Empty      = "empty"
+)
+
+// Kinds lists every kind, in the order of the constants above.
+var Kinds = []string{Executable, Script, Image, Audio, Video, Archive, Document, Text, Binary, Empty}
+
+// HeaderSize is how much of a file is read; tar's magic number ends at
+// byte 262.
+const HeaderSize = 1024
+
+// Result is the detected type of some content.
+type Result struct {
+	MIME string
+	Kind string
+}
+
+// File detects the type of the file at path from its first HeaderSize
+// bytes.
+func File(path string) (Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Result{}, err
+	}
+	defer f.Close()
+	header := make([]byte, HeaderSize)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return Result{}, err
+	}
+	return Detect(header[:n]), nil
+}
+
+// magic is a byte signature at a fixed offset.
+type magic struct {
+	offset int
+	sig    string
+	mime   string
+	kind   string
+}
+
+// signatures are checked in order, so longer signatures sharing a prefix
+// with shorter ones come first.
+var signatures = []magic{
+	{0, "\x89PNG\r\n\x1a\n", "image/png", Image},
+	{0, "\xff\xd8\xff", "image/jpeg", Image},
+	{0, "GIF87a", "image/gif", Image},
+	{0, "GIF89a", "image/gif", Image},
+	{0, "BM", "image/bmp", Image},
+	{0, "II*\x00", "image/tiff", Image},
+	{0, "MM\x00*", "image/tiff", Image},
+	{0, "\x00\x00\x01\x00", "image/vnd.microsoft.icon", Image},
+	{0, "8BPS", "image/vnd.adobe.photoshop", Image},
+	{0, "ID3", "audio/mpeg", Audio},
+	{0, "fLaC", "audio/flac", Audio},
+	{0, "OggS", "audio/ogg", Audio},
+	{0, "MThd", "audio/midi", Audio},
+	{0, "\x1aE\xdf\xa3", "video/x-matroska", Video},
+	{0, "%PDF-", "application/pdf", Document},
+	{0, "%!PS", "application/postscript", Document},
+	{0, "{\\rtf", "text/rtf", Document},
+	{0, "\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1", "application/x-ole-storage", Document},
+	{0, "PK\x03\x04", "application/zip", Archive},
+	{0, "PK\x05\x06", "application/zip", Archive},
+	{0, "\x1f\x8b", "application/gzip", Archive},
+	{0, "BZh", "application/x-bzip2", Archive},
+	{0, "\xfd7zXZ\x00", "application/x-xz", Archive},
+	{0, "(\xb5/\xfd", "application/zstd", Archive},
+	{0, "7z\xbc\xaf\x27\x1c", "application/x-7z-compressed", Archive},
+	{0, "Rar!\x1a\x07", "application/vnd.rar", Archive},
+	{0, "\x04\x22\x4d\x18", "application/x-lz4", Archive},
+	{0, "!<arch>\n", "application/x-archive", Archive},
+	{0, "\xed\xab\xee\xdb", "application/x-rpm", Archive},
+	{0, "SQLite format 3\x00", "application/vnd.sqlite3", Binary},
+	{0, "\x00asm", "application/wasm", Executable},
+	{257, "ustar", "application/x-tar", Archive},
+}
+
+// confirm checks more of the header for short signatures that text could
+// start with.
+var confirm = map[string]func(b []byte) bool{
+	"image/bmp":           bmpHeader,
+	"application/x-bzip2": func(b []byte) bool { return len(b) > 3 && b[3] >= '1' && b[3] <= '9' },
+}
+
+// Detect identifies content from its first bytes, which should be the
+// first HeaderSize bytes of a file or all of a shorter one.
+func Detect(b []byte) Result {
+	if len(b) == 0 {
+		return Result{"inode/x-empty", Empty}
+	}
+	if r, ok := executable(b); ok {
+		return r
+	}
+	if r, ok := riff(b); ok {
+		return r
+	}
+	if len(b) >= 12 && string(b[4:8]) == "ftyp" {
+		return isoMedia(string(b[8:12]))
+	}
+	for _, m := range signatures {
+		if len(b) >= m.offset+len(m.sig) && string(b[m.offset:m.offset+len(m.sig)]) == m.sig && (confirm[m.mime] == nil || confirm[m.mime](b)) {
+			return Result{m.mime, m.kind}
+		}
+	}
+	if bytes.HasPrefix(b, []byte("#!")) {
+		return script(b)
+	}
+	return text(b)
+}
+
+// executable recognises ELF, Mach-O and PE binaries.
+func executable(b []byte) (Result, bool) {
+	switch {
+	case len(b) >= 18 && string(b[:4]) == "\x7fELF":
+		order := binary.ByteOrder(binary.LittleEndian)
+		if b[5] == 2 {
+			order = binary.BigEndian
+		}
+		switch order.Uint16(b[16:]) {
+		case 1:
+			return Result{"application/x-object", Executable}, true
+		case 3:
+			return Result{"application/x-sharedlib", Executable}, true
+		case 4:
+			return Result{"application/x-coredump", Binary}, true
+		}
+		return Result{"application/x-executable", Executable}, true
+	case len(b) >= 4 && machO(b[:4]):
+		return Result{"application/x-mach-binary", Executable}, true
+	case len(b) >= 8 && string(b[:4]) == "\xca\xfe\xba\xbe" && binary.BigEndian.Uint32(b[4:]) < 45:
+		// Universal binaries share their magic with Java class files,
+		// which store a version of 45 or more in the same place.
+		return Result{"application/x-mach-binary", Executable}, true
+	case len(b) >= 4 && string(b[:4]) == "\xca\xfe\xba\xbe":
+		return Result{"application/java-vm", Executable}, true
+	case len(b) >= 0x40 && string(b[:2]) == "MZ":
+		peOffset := int(binary.LittleEndian.Uint32(b[0x3c:]))
+		if peOffset+4 <= len(b) && string(b[peOffset:peOffset+4]) == "PE\x00\x00" {
+			return Result{"application/vnd.microsoft.portable-executable", Executable}, true
+		}
+		return Result{"application/x-dosexec", Executable}, true
+	}
+	return Result{}, false
+}
+
+// bmpHeader checks the size of the DIB header that follows "BM".
+func bmpHeader(b []byte) bool {
+	if len(b) < 18 {
+		return false
+	}
+	switch binary.LittleEndian.Uint32(b[14:]) {
+	case 12, 40, 52, 56, 64, 108, 124:
+		return true
+	}
+	return false
+}
+
+func machO(b []byte) bool {
+	switch binary.BigEndian.Uint32(b) {
+	case 0xfeedface, 0xfeedfacf, 0xcefaedfe, 0xcffaedfe:
+		return true
+	}
+	return false
+}
+
+// riff recognises the RIFF containers: WAVE, AVI and WebP.
+func riff(b []byte) (Result, bool) {
+	if len(b) < 12 || string(b[:4]) != "RIFF" {
+		return Result{}, false
+	}
+	switch string(b[8:12]) {
+	case "WAVE":
+		return Result{"audio/wav", Audio}, true
+	case "AVI ":
+		return Result{"video/x-msvideo", Video}, true
+	case "WEBP":
+		return Result{"image/webp", Image}, true
+	}
+	return Result{"application/octet-stream", Binary}, true
+}
+
+// isoMedia maps the major brand of an ISO base media file.
+func isoMedia(brand string) Result {
+	switch {
+	case brand == "avif" || brand == "avis":
+		return Result{"image/avif", Image}
+	case brand == "heic" || brand == "heix" || brand == "mif1":
+		return Result{"image/heic", Image}
+	case brand == "M4A " || brand == "M4B ":
+		return Result{"audio/mp4", Audio}
+	case brand == "qt  ":
+		return Result{"video/quicktime", Video}
+	case strings.HasPrefix(brand, "3g"):
+		return Result{"video/3gpp", Video}
+	}
+	return Result{"video/mp4", Video}
+}
+
+// interpreters maps script interpreters to their MIME types.
+var interpreters = map[string]string{
+	"sh":     "text/x-shellscript",
+	"bash":   "text/x-shellscript",
+	"dash":   "text/x-shellscript",
+	"zsh":    "text/x-shellscript",
+	"ksh":    "text/x-shellscript",
+	"fish":   "text/x-shellscript",
+	"python": "text/x-script.python",
+	"perl":   "text/x-perl",
+	"ruby":   "text/x-ruby",
+	"node":   "text/javascript",
+	"php":    "text/x-php",
+	"lua":    "text/x-lua",
+	"tclsh":  "text/x-tcl",
+	"awk":    "text/x-awk",
+}
+
+// script identifies a #! script by its interpreter, looking past env and
+// its options, and ignoring version numbers such as python3.12.
+func script(b []byte) Result {
+	line, _, _ := bytes.Cut(b[2:], []byte("\n"))
+	fields := strings.Fields(string(line))
+	if len(fields) > 0 && path.Base(fields[0]) == "env" {
+		fields = fields[1:]
+		for len(fields) > 0 && strings.HasPrefix(fields[0], "-") {
+			fields = fields[1:]
+		}
+	}
+	if len(fields) == 0 {
+		return Result{"text/plain", Script}
+	}
+	name := strings.TrimRight(path.Base(fields[0]), "0123456789.")
+	if mime, ok := interpreters[name]; ok {
+		return Result{mime, Script}
+	}
+	return Result{"text/x-script." + name, Script}
+}
+
+// text tells text, by encoding, from binary data.
+func text(b []byte) Result {
+	boms := []struct{ bom, charset string }{
+		{"\xef\xbb\xbf", "utf-8"},
+		{"\x00\x00\xfe\xff", "utf-32be"},
+		{"\xff\xfe\x00\x00", "utf-32le"},
+		{"\xfe\xff", "utf-16be"},
+		{"\xff\xfe", "utf-16le"},
+	}
+	for _, e := range boms {
+		if bytes.HasPrefix(b, []byte(e.bom)) {
+			return textResult(b[len(e.bom):], e.charset)
+		}
+	}
+	if bytes.IndexByte(b, 0) >= 0 || bytes.ContainsFunc(b, binaryRune) {
+		return Result{"application/octet-stream", Binary}
+	}
+	if !validUTF8(b) {
+		// Bytes above 0x7f that are not UTF-8 are most likely Latin-1,
+		// unless they are C1 control codes.
+		for _, c := range b {
+			if c >= 0x80 && c < 0xa0 {
+				return Result{"application/octet-stream", Binary}
+			}
+		}
+		return textResult(b, "iso-8859-1")
+	}
+	for _, c := range b {
+		if c >= utf8.RuneSelf {
+			return textResult(b, "utf-8")
+		}
+	}
+	return textResult(b, "us-ascii")
+}
+
+// textResult refines text/plain for markup that announces itself.
+func textResult(b []byte, charset string) Result {
+	mime := "text/plain"
+	start := strings.ToLower(string(bytes.TrimLeft(b[:min(len(b), 256)], " \t\r\n")))
+	switch {
+	case strings.HasPrefix(start, "<?xml"):
+		mime = "text/xml"
+		if strings.Contains(start, "<svg") {
+			return Result{"image/svg+xml", Image}
+		}
+	case strings.HasPrefix(start, "<!doctype html"), strings.HasPrefix(start, "<html"):
+		mime = "text/html"
+	case strings.HasPrefix(start, "<svg"):
+		return Result{"image/svg+xml", Image}
+	}
+	return Result{mime + "; charset=" + charset, Text}
+}
+
+// binaryRune reports control characters that do not occur in text.
+func binaryRune(r rune) bool {
+	return r < 0x20 && r != '\t' && r != '\n' && r != '\r' && r != '\f' && r != '\b' && r != '\v' && r != 0x1b
+}
+
+// validUTF8 is utf8.Valid, allowing a sequence cut off at the end of the
+// header.
+func validUTF8(b []byte) bool {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				b = b[:i]
+			}
+			break
+		}
+	}
+	return utf8.Valid(b)
+}
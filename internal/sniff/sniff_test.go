@@ -0,0 +1,88 @@
+package sniff
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func elf(typ byte) string {
+	return "\x7fELF\x02\x01\x01" + strings.Repeat("\x00", 9) + string([]byte{typ, 0})
+}
+
+func TestDetect(t *testing.T) {
+	pe := "MZ" + strings.Repeat("\x00", 0x3a) + "\x40\x00\x00\x00" + "PE\x00\x00"
+	tar := strings.Repeat("\x00", 257) + "ustar\x0000"
+	tests := []struct {
+		name, content, mime, kind string
+	}{
+		{"empty", "", "inode/x-empty", Empty},
+		{"elf executable", elf(2), "application/x-executable", Executable},
+		{"elf shared object", elf(3), "application/x-sharedlib", Executable},
+		{"elf relocatable", elf(1), "application/x-object", Executable},
+		{"mach-o", "\xcf\xfa\xed\xfe\x07\x00\x00\x01", "application/x-mach-binary", Executable},
+		{"universal binary", "\xca\xfe\xba\xbe\x00\x00\x00\x02", "application/x-mach-binary", Executable},
+		{"java class", "\xca\xfe\xba\xbe\x00\x00\x00\x41", "application/java-vm", Executable},
+		{"pe", pe, "application/vnd.microsoft.portable-executable", Executable},
+		{"dos", "MZ" + strings.Repeat("\x00", 0x3e), "application/x-dosexec", Executable},
+		{"sh", "#!/bin/sh\necho hi\n", "text/x-shellscript", Script},
+		{"env python", "#!/usr/bin/env -S python3.12 -u\n", "text/x-script.python", Script},
+		{"perl", "#! /usr/bin/perl -w\n", "text/x-perl", Script},
+		{"unknown interpreter", "#!/opt/bin/janet\n", "text/x-script.janet", Script},
+		{"png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", "image/png", Image},
+		{"jpeg", "\xff\xd8\xff\xe0\x00\x10JFIF", "image/jpeg", Image},
+		{"gif", "GIF89a\x01\x00", "image/gif", Image},
+		{"bmp", "BM" + strings.Repeat("\x00", 12) + "\x28\x00\x00\x00", "image/bmp", Image},
+		{"webp", "RIFF\x00\x00\x00\x00WEBPVP8 ", "image/webp", Image},
+		{"svg", "<?xml version=\"1.0\"?>\n<svg xmlns=\"http://www.w3.org/2000/svg\"/>", "image/svg+xml", Image},
+		{"heic", "\x00\x00\x00\x18ftypheic", "image/heic", Image},
+		{"wav", "RIFF\x00\x00\x00\x00WAVEfmt ", "audio/wav", Audio},
+		{"flac", "fLaC\x00\x00\x00\x22", "audio/flac", Audio},
+		{"mp4", "\x00\x00\x00\x20ftypisom", "video/mp4", Video},
+		{"mkv", "\x1aE\xdf\xa3\x9f", "video/x-matroska", Video},
+		{"zip", "PK\x03\x04\x14\x00", "application/zip", Archive},
+		{"gzip", "\x1f\x8b\x08\x00", "application/gzip", Archive},
+		{"bzip2", "BZh91AY&SY", "application/x-bzip2", Archive},
+		{"xz", "\xfd7zXZ\x00\x00", "application/x-xz", Archive},
+		{"zstd", "\x28\xb5\x2f\xfd\x04", "application/zstd", Archive},
+		{"tar", tar, "application/x-tar", Archive},
+		{"pdf", "%PDF-1.7\n", "application/pdf", Document},
+		{"ascii", "hello, world\n", "text/plain; charset=us-ascii", Text},
+		{"utf-8", "héllo wörld\n", "text/plain; charset=utf-8", Text},
+		{"utf-8 bom", "\xef\xbb\xbfhello", "text/plain; charset=utf-8", Text},
+		{"utf-16", "\xff\xfeh\x00i\x00", "text/plain; charset=utf-16le", Text},
+		{"latin-1", "caf\xe9 cr\xe8me\n", "text/plain; charset=iso-8859-1", Text},
+		{"html", "\n<!DOCTYPE html>\n<html>", "text/html; charset=us-ascii", Text},
+		{"text starting like bmp", "BMW and Audi\n", "text/plain; charset=us-ascii", Text},
+		{"text starting like bzip2", "BZhello\n", "text/plain; charset=us-ascii", Text},
+		{"binary with nul", "abc\x00def", "application/octet-stream", Binary},
+		{"binary control bytes", "abc\x01\x02", "application/octet-stream", Binary},
+		{"c1 controls", "abc\x85\x90", "application/octet-stream", Binary},
+		{"sqlite", "SQLite format 3\x00", "application/vnd.sqlite3", Binary},
+	}
+	for _, tt := range tests {
+		got := Detect([]byte(tt.content))
+		require.Equal(t, Result{tt.mime, tt.kind}, got, tt.name)
+	}
+}
+
+func TestDetectTruncatedUTF8(t *testing.T) {
+	// A multi-byte character cut off by the header size is still text.
+	b := []byte(strings.Repeat("é", HeaderSize/2))
+	require.Equal(t, Text, Detect(b[:HeaderSize-1]).Kind)
+}
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "run.sh")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/bash\n"+strings.Repeat("echo\n", 1000)), 0o755))
+	r, err := File(path)
+	require.NoError(t, err)
+	require.Equal(t, Result{"text/x-shellscript", Script}, r)
+
+	_, err = File(filepath.Join(dir, "missing"))
+	require.Error(t, err)
+}
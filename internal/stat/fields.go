@@ -0,0 +1,83 @@
+package stat
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Field is a field of an entry as encoding/json writes it.
+type Field struct {
+	// Name is the JSON key of the field. Fields returned by Leaves are named
+	// by the keys from the top of the entry joined with dots, e.g.
+	// "permissions.symbolic.owner.Read".
+	Name string
+	// Index is the index sequence of the field for reflect.Value.FieldByIndex.
+	Index []int
+	Type  reflect.Type
+	// OmitEmpty is set when the field is left out of the JSON when empty.
+	OmitEmpty bool
+}
+
+// Fields returns the fields encoding/json writes for the struct type t, in
+// order, with the fields of an embedded struct in place of the struct.
+func Fields(t reflect.Type) []Field {
+	return fieldsOf(t, nil)
+}
+
+func fieldsOf(t reflect.Type, index []int) []Field {
+	var fields []Field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		fieldIndex := append(append([]int(nil), index...), i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			fields = append(fields, fieldsOf(f.Type, fieldIndex)...)
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, Field{
+			Name:      name,
+			Index:     fieldIndex,
+			Type:      f.Type,
+			OmitEmpty: strings.Contains(opts, "omitempty"),
+		})
+	}
+	return fields
+}
+
+// Leaves returns the leaf fields of a StatLink in the order of its JSON
+// encoding, with the leaves of a nested object in place of the object.
+// Optional objects such as elf are flattened too; reading their leaves
+// fails when the object is absent. Timestamps, lists and maps are leaves.
+func Leaves() []Field {
+	return LeavesOf(reflect.TypeOf(StatLink{}))
+}
+
+// LeavesOf is Leaves for another struct type, such as one embedding a Stat.
+func LeavesOf(t reflect.Type) []Field {
+	return leavesOf(t, nil, "")
+}
+
+func leavesOf(t reflect.Type, index []int, prefix string) []Field {
+	var leaves []Field
+	for _, f := range fieldsOf(t, index) {
+		f.Name = prefix + f.Name
+		switch {
+		case f.Type.Kind() == reflect.Struct && f.Type != reflect.TypeOf(Time{}):
+			leaves = append(leaves, leavesOf(f.Type, f.Index, f.Name+".")...)
+		case f.Type.Kind() == reflect.Pointer && f.Type.Elem().Kind() == reflect.Struct:
+			leaves = append(leaves, leavesOf(f.Type.Elem(), f.Index, f.Name+".")...)
+		default:
+			leaves = append(leaves, f)
+		}
+	}
+	return leaves
+}
@@ -0,0 +1,58 @@
+package stat
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func names(fields []Field) []string {
+	var n []string
+	for _, f := range fields {
+		n = append(n, f.Name)
+	}
+	return n
+}
+
+func TestFields(t *testing.T) {
+	fields := Fields(reflect.TypeOf(StatLink{}))
+	n := names(fields)
+	// Stat is embedded, so its fields come first and in its place.
+	require.Equal(t, []string{"schema_version", "size_bytes", "size_human"}, n[:3])
+	require.Equal(t, []string{"targets", "dangling"}, n[len(n)-2:])
+	require.Contains(t, n, "permissions")
+	require.NotContains(t, n, "Stat")
+
+	require.False(t, fields[0].OmitEmpty)
+	require.True(t, fields[2].OmitEmpty)
+	require.Equal(t, []int{0, 2}, fields[2].Index)
+}
+
+func TestLeaves(t *testing.T) {
+	leaves := Leaves()
+	n := names(leaves)
+	require.Contains(t, n, "permissions.octal")
+	require.Contains(t, n, "permissions.symbolic.owner.Read")
+	require.Contains(t, n, "device.major")
+	require.Contains(t, n, "elf.type")
+	require.Contains(t, n, "last_modified_time")
+	require.Contains(t, n, "hashes")
+	require.NotContains(t, n, "permissions")
+	require.NotContains(t, n, "elf")
+
+	link := StatLink{Targets: []string{"/a"}}
+	link.Permissions.Symbolic.Owner.Read = true
+	v := reflect.ValueOf(link)
+	for _, f := range leaves {
+		switch f.Name {
+		case "permissions.symbolic.owner.Read":
+			require.True(t, v.FieldByIndex(f.Index).Bool())
+		case "targets":
+			require.Equal(t, []string{"/a"}, v.FieldByIndex(f.Index).Interface())
+		case "elf.type":
+			_, err := v.FieldByIndexErr(f.Index)
+			require.Error(t, err)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package stat
+
+import "syscall"
+
+// ModeString renders the file type and permission bits the way ls -l
+// does, e.g. "drwxr-sr-x" or "-rwsr-xr-x".
+func (s Stat) ModeString() string {
+	typeChar := map[string]byte{
+		DirectoryFileType:    'd',
+		SymbolicLinkFileType: 'l',
+		BlockDeviceFileType:  'b',
+		CharDeviceFileType:   'c',
+		FifoFileType:         'p',
+		SocketFileType:       's',
+		RegularFileType:      '-',
+	}[s.Type]
+	if typeChar == 0 {
+		typeChar = '?'
+	}
+
+	symbolic := s.Permissions.Symbolic
+	b := []byte{typeChar}
+	b = append(b, symbolic.Owner.String()...)
+	b = append(b, symbolic.Group.String()...)
+	b = append(b, symbolic.Other.String()...)
+
+	// The set-id and sticky bits share a column with execute: lowercase
+	// when execute is also set, uppercase when it is not.
+	special := func(idx int, set bool, lower, upper byte) {
+		if !set {
+			return
+		}
+		if b[idx] == 'x' {
+			b[idx] = lower
+		} else {
+			b[idx] = upper
+		}
+	}
+	mode := uint32(s.Mode)
+	special(3, mode&syscall.S_ISUID != 0, 's', 'S')
+	special(6, mode&syscall.S_ISGID != 0, 's', 'S')
+	special(9, mode&syscall.S_ISVTX != 0, 't', 'T')
+	return string(b)
+}
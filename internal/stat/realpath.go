@@ -0,0 +1,69 @@
+package stat
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// maxRealPathHops matches the kernel's MAXSYMLINKS limit on Linux.
+const maxRealPathHops = 40
+
+// RealPath returns the canonical form of an absolute path, with every
+// symbolic link along the way resolved, not only the final component.
+func RealPath(p string) (string, error) {
+	return RealPathWithDeps(p, syscall.Lstat, os.Readlink)
+}
+
+// RealPathWithDeps resolves p one component at a time. Once a component is
+// missing, the rest of the path is kept as written (like `realpath -m`) so
+// that dangling links still get a useful answer.
+func RealPathWithDeps(
+	p string,
+	lstat func(string, *syscall.Stat_t) error,
+	readlink func(string) (string, error),
+) (string, error) {
+	if !filepath.IsAbs(p) {
+		return "", fmt.Errorf("cannot canonicalise relative path %s", p)
+	}
+	resolved := "/"
+	rest := strings.Split(p, "/")
+	hops := 0
+	for len(rest) > 0 {
+		name := rest[0]
+		rest = rest[1:]
+		switch name {
+		case "", ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+
+		next := filepath.Join(resolved, name)
+		var s syscall.Stat_t
+		if err := lstat(next, &s); err != nil {
+			return filepath.Join(append([]string{next}, rest...)...), nil
+		}
+		if s.Mode&syscall.S_IFMT != syscall.S_IFLNK {
+			resolved = next
+			continue
+		}
+
+		hops++
+		if hops > maxRealPathHops {
+			return "", fmt.Errorf("symlink loop detected at %s", next)
+		}
+		target, err := readlink(next)
+		if err != nil {
+			return "", fmt.Errorf("error reading symlink %s: %w", next, err)
+		}
+		if filepath.IsAbs(target) {
+			resolved = "/"
+		}
+		rest = append(strings.Split(target, "/"), rest...)
+	}
+	return resolved, nil
+}
@@ -0,0 +1,79 @@
+package stat
+
+import (
+	"fmt"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTree backs lstat and readlink with a map of links; every other path
+// under /srv exists as a directory.
+type fakeTree map[string]string
+
+func (f fakeTree) lstat(path string, stat *syscall.Stat_t) error {
+	if _, ok := f[path]; ok {
+		stat.Mode = syscall.S_IFLNK
+		return nil
+	}
+	if path == "/srv/missing" || path == "/srv/app/missing" {
+		return fmt.Errorf("file does not exist: %s", path)
+	}
+	stat.Mode = syscall.S_IFDIR
+	return nil
+}
+
+func (f fakeTree) readlink(path string) (string, error) {
+	if target, ok := f[path]; ok {
+		return target, nil
+	}
+	return "", fmt.Errorf("not a link: %s", path)
+}
+
+func TestRealPathResolvesIntermediateLinks(t *testing.T) {
+	tree := fakeTree{
+		"/srv/app":             "deploys",
+		"/srv/deploys/current": "releases/3",
+	}
+
+	result, err := RealPathWithDeps("/srv/app/current/bin", tree.lstat, tree.readlink)
+	require.NoError(t, err)
+	require.Equal(t, "/srv/deploys/releases/3/bin", result)
+}
+
+func TestRealPathAbsoluteTargetAndDotDot(t *testing.T) {
+	tree := fakeTree{
+		"/srv/link": "/opt/tool/../tool/bin",
+	}
+
+	result, err := RealPathWithDeps("/srv/./link/../etc", tree.lstat, tree.readlink)
+	require.NoError(t, err)
+	require.Equal(t, "/opt/tool/etc", result)
+}
+
+func TestRealPathMissingComponent(t *testing.T) {
+	tree := fakeTree{
+		"/srv/app": "missing",
+	}
+
+	result, err := RealPathWithDeps("/srv/app/conf", tree.lstat, tree.readlink)
+	require.NoError(t, err)
+	require.Equal(t, "/srv/missing/conf", result)
+}
+
+func TestRealPathLoop(t *testing.T) {
+	tree := fakeTree{
+		"/srv/a": "b",
+		"/srv/b": "a",
+	}
+
+	_, err := RealPathWithDeps("/srv/a", tree.lstat, tree.readlink)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "symlink loop detected")
+}
+
+func TestRealPathRelative(t *testing.T) {
+	_, err := RealPathWithDeps("srv/a", fakeTree{}.lstat, fakeTree{}.readlink)
+	require.Error(t, err)
+}
@@ -3,7 +3,14 @@ package stat
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/sochoa/go-ls/internal/elfinfo"
+	"github.com/sochoa/go-ls/internal/extent"
+	"github.com/sochoa/go-ls/internal/fsinfo"
+	"github.com/sochoa/go-ls/internal/mount"
 	"github.com/sochoa/go-ls/internal/perm"
+	"github.com/sochoa/go-ls/internal/size"
+	"golang.org/x/sys/unix"
+	"math"
 	"os"
 	"os/user"
 	"path"
@@ -16,23 +23,33 @@ type CommonStat interface {
 	Json(pretty bool) (string, error)
 	GetType() string
 	GetAbsolutePath() string
+	GetStat() Stat
 }
 
+// SchemaVersion identifies the shape of the JSON encoding of Stat and
+// StatLink. It changes whenever a field is added, removed or retyped.
+const SchemaVersion = "12"
+
 type Stat struct {
+	SchemaVersion          string    `json:"schema_version"`
 	SizeBytes              int64     `json:"size_bytes"`
+	SizeHuman              string    `json:"size_human,omitempty"`
 	Mode                   uint16    `json:"mode"`
 	UserID                 uint32    `json:"user_id"`
 	UserName               string    `json:"user_name"`
 	GroupID                uint32    `json:"group_id"`
 	GroupName              string    `json:"group_name"`
 	Owner                  string    `json:"owner"`
-	LastAccessedTime       time.Time `json:"last_accessed_time"`
-	LastModifiedTime       time.Time `json:"last_modified_time"`
-	CreateTime             time.Time `json:"create_time"`
-	BirthTime              time.Time `json:"birth_time"`
+	LastAccessedTime       Time      `json:"last_accessed_time"`
+	LastModifiedTime       Time      `json:"last_modified_time"`
+	CreateTime             Time      `json:"create_time"`
+	BirthTime              Time      `json:"birth_time"`
 	BlockSize              uint32    `json:"block_size"`
 	NumBlocks              uint64    `json:"num_blocks"`
-	HardLinkReferenceCount uint16    `json:"hard_link_reference_count"`
+	HardLinkReferenceCount uint64    `json:"hard_link_reference_count"`
+	Inode                  uint64    `json:"inode"`
+	Device                 DeviceID  `json:"device"`
+	Rdev                   *DeviceID `json:"rdev,omitempty"`
 	Permissions            struct {
 		Octal    string `json:"octal"`
 		Symbolic struct {
@@ -44,12 +61,62 @@ type Stat struct {
 	BaseName     string `json:"basename"`
 	AbsolutePath string `json:"absolute_path"`
 	Type         string `json:"type"`
+	RealPath     string `json:"realpath,omitempty"`
+	GitStatus    string `json:"git_status,omitempty"`
+	// Hashes are hex digests of the content of regular files by algorithm.
+	Hashes map[string]string `json:"hashes,omitempty"`
+	// MimeType and Kind describe the content of regular files, as sniffed
+	// from their first bytes.
+	MimeType string `json:"mime_type,omitempty"`
+	Kind     string `json:"kind,omitempty"`
+	// ELF describes ELF executables, libraries and objects.
+	ELF *elfinfo.ELF `json:"elf,omitempty"`
+	// Sparse is the fraction of a regular file's size that has no blocks
+	// allocated to it, from 0 for a dense file up to 1.
+	Sparse float64 `json:"sparse,omitempty"`
+	// Extents are the data and hole ranges of regular files.
+	Extents []extent.Extent `json:"extents,omitempty"`
+	// Mount is the mount the entry lives on, and IsMountPoint is set when
+	// something is mounted on the entry itself.
+	Mount        *mount.Mount `json:"mount,omitempty"`
+	IsMountPoint bool         `json:"is_mount_point,omitempty"`
+	// FileSystem is the capacity of the file system of command line
+	// arguments, with --fs-info.
+	FileSystem *fsinfo.FileSystem `json:"filesystem,omitempty"`
 }
 
 var _ CommonStat = (*Stat)(nil)
 
+// DeviceID is a device number split into its major and minor parts. Device
+// in Stat is the file system an entry lives on, and Rdev the device that a
+// block or character device file stands for.
+type DeviceID struct {
+	Major uint32 `json:"major"`
+	Minor uint32 `json:"minor"`
+}
+
+// String formats d as ls does in place of the size of a device file.
+func (d DeviceID) String() string {
+	return fmt.Sprintf("%d, %d", d.Major, d.Minor)
+}
+
+// sparseness is the share of n bytes not covered by allocated bytes, to
+// four decimal places. Files with more allocated than their size, which is
+// usual for small files, are not sparse.
+func sparseness(n, allocated int64) float64 {
+	if n <= 0 || allocated >= n {
+		return 0
+	}
+	return math.Round(float64(n-allocated)/float64(n)*1e4) / 1e4
+}
+
+// deviceID splits dev with the encoding of the running system.
+func deviceID(dev uint64) DeviceID {
+	return DeviceID{Major: unix.Major(dev), Minor: unix.Minor(dev)}
+}
+
 func New(n string, stat *syscall.Stat_t) Stat {
-	return NewWithDeps(n, stat, user.Lookup, path.Base, filepath.Abs)
+	return NewWithDeps(n, stat, user.LookupId, user.LookupGroupId, path.Base, filepath.Abs)
 }
 
 func (s Stat) Json(pretty bool) (string, error) {
@@ -76,6 +143,10 @@ func (s Stat) GetAbsolutePath() string {
 	return s.AbsolutePath
 }
 
+func (s Stat) GetStat() Stat {
+	return s
+}
+
 const (
 	SymbolicLinkFileType = "symlink"
 	BlockDeviceFileType  = "block_device"
@@ -91,6 +162,7 @@ func NewWithDeps(
 	n string,
 	stat *syscall.Stat_t,
 	userLookup func(uid string) (*user.User, error),
+	groupLookup func(gid string) (*user.Group, error),
 	pathBasename func(string) string,
 	pathAbs func(string) (string, error),
 ) Stat {
@@ -98,6 +170,7 @@ func NewWithDeps(
 		m   Stat
 		err error
 	)
+	m.SchemaVersion = SchemaVersion
 	m.BaseName = pathBasename(n)
 	m.AbsolutePath, err = pathAbs(n)
 	if err != nil {
@@ -128,10 +201,10 @@ func NewWithDeps(
 	m.Mode = stat.Mode
 	m.UserID = stat.Uid
 	m.GroupID = stat.Gid
-	m.LastAccessedTime = time.Unix(stat.Atimespec.Sec, stat.Atimespec.Nsec)
-	m.LastModifiedTime = time.Unix(stat.Mtimespec.Sec, stat.Mtimespec.Nsec)
-	m.CreateTime = time.Unix(stat.Ctimespec.Sec, stat.Ctimespec.Nsec)
-	m.BirthTime = time.Unix(stat.Birthtimespec.Sec, stat.Birthtimespec.Nsec)
+	m.LastAccessedTime = Time{Time: time.Unix(stat.Atimespec.Sec, stat.Atimespec.Nsec)}
+	m.LastModifiedTime = Time{Time: time.Unix(stat.Mtimespec.Sec, stat.Mtimespec.Nsec)}
+	m.CreateTime = Time{Time: time.Unix(stat.Ctimespec.Sec, stat.Ctimespec.Nsec)}
+	m.BirthTime = Time{Time: time.Unix(stat.Birthtimespec.Sec, stat.Birthtimespec.Nsec)}
 	m.BlockSize = uint32(stat.Blksize)
 	m.NumBlocks = uint64(stat.Blocks)
 
@@ -144,14 +217,23 @@ func NewWithDeps(
 		m.UserName = "unknown"
 	}
 
-	u, err = userLookup(fmt.Sprintf("%d", stat.Gid))
+	g, err := groupLookup(fmt.Sprintf("%d", stat.Gid))
 	if err == nil {
-		m.GroupName = u.Username
+		m.GroupName = g.Name
 	} else {
 		m.GroupName = "unknown"
 	}
 
-	m.HardLinkReferenceCount = uint16(stat.Nlink)
+	m.HardLinkReferenceCount = uint64(stat.Nlink)
+	if m.Type == RegularFileType {
+		m.Sparse = sparseness(m.SizeBytes, size.Allocated(m.NumBlocks))
+	}
+	m.Inode = stat.Ino
+	m.Device = deviceID(uint64(stat.Dev))
+	if m.Type == BlockDeviceFileType || m.Type == CharDeviceFileType {
+		rdev := deviceID(uint64(stat.Rdev))
+		m.Rdev = &rdev
+	}
 
 	octalPerm := os.FileMode(stat.Mode) & os.ModePerm
 	m.Permissions.Octal = fmt.Sprintf("%o", octalPerm)
@@ -159,7 +241,7 @@ func NewWithDeps(
 	const (
 		ownerStatTOffset = 6
 		groupStatTOffset = 3
-		otherStatTOffset = 1
+		otherStatTOffset = 0
 	)
 
 	// https://man7.org/linux/man-pages/man7/inode.7.html
@@ -2,7 +2,9 @@ package stat
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"slices"
@@ -12,6 +14,9 @@ import (
 type StatLink struct {
 	Stat
 	Targets []string `json:"targets"`
+	// Dangling is set when the chain of links ends at a path that does not
+	// exist or loops back to a link already in Targets.
+	Dangling bool `json:"dangling"`
 }
 
 var _ CommonStat = (*StatLink)(nil)
@@ -20,6 +25,15 @@ func (s StatLink) GetType() string {
 	return s.Type
 }
 
+// Target is the last path reached while following the link. For a dangling
+// link this is the path that does not exist.
+func (s StatLink) Target() string {
+	if len(s.Targets) == 0 {
+		return ""
+	}
+	return s.Targets[len(s.Targets)-1]
+}
+
 func (s StatLink) Json(pretty bool) (string, error) {
 	var (
 		statBytes []byte
@@ -41,11 +55,7 @@ func NewLink(j CommonStat) (*StatLink, error) {
 	if s.Type != SymbolicLinkFileType {
 		return nil, nil
 	}
-	sl := &StatLink{
-		Stat:    s,
-		Targets: []string{},
-	}
-	return NewLinkWithDeps(sl, os.Readlink, syscall.Lstat, filepath.IsAbs, filepath.Join, filepath.Dir)
+	return NewLinkWithDeps(s, os.Readlink, syscall.Lstat, filepath.IsAbs, filepath.Join, filepath.Dir)
 }
 
 func NewLinkWithDeps(
@@ -68,11 +78,23 @@ func NewLinkWithDeps(
 	for i := 0; i < 10; i++ {
 		var linkStat syscall.Stat_t
 		err := lstat(currentPath, &linkStat)
-		if err != nil {
+		if i > 0 && (errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ENOTDIR)) {
+			// The link itself exists but something it points at does not, so
+			// keep the chain we walked and report the link as dangling.
+			// Other errors, such as EACCES on a parent, say nothing about
+			// whether the target exists.
+			sl.Targets = append(sl.Targets, currentPath)
+			sl.Dangling = true
+			break
+		} else if err != nil {
 			return nil, fmt.Errorf("error lstat-ing %s: %w", currentPath, err)
 		}
 		if slices.Contains(sl.Targets, currentPath) {
-			return nil, fmt.Errorf("symlink loop detected at %s", currentPath)
+			// The chain loops back on itself and never reaches a file, so
+			// the link is broken like one to a missing path. Targets ends
+			// with the last link before the chain repeats.
+			sl.Dangling = true
+			break
 		}
 		sl.Targets = append(sl.Targets, currentPath)
 		if linkStat.Mode&syscall.S_IFMT != syscall.S_IFLNK {
@@ -106,7 +106,7 @@ func TestNewLinkWithDepsBrokenSymlink(t *testing.T) {
 			stat.Mode = syscall.S_IFLNK
 			return nil
 		}
-		return fmt.Errorf("file does not exist: %s", path) // Simulate broken link for "/path/to/missing"
+		return fmt.Errorf("lstat %s: %w", path, syscall.ENOENT) // Simulate broken link for "/path/to/missing"
 	}
 	mockReadlink := func(path string) (string, error) {
 		if path == "/path/to/symlink" {
@@ -118,6 +118,68 @@ func TestNewLinkWithDepsBrokenSymlink(t *testing.T) {
 	mockFilepathJoin := filepath.Join
 	mockFilepathDir := filepath.Dir
 
+	result, err := NewLinkWithDeps(j, mockReadlink, mockLstat, mockIsAbs, mockFilepathJoin, mockFilepathDir)
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	require.True(t, result.Dangling)
+	require.Equal(t, []string{"/path/to/symlink", "/path/to/missing"}, result.Targets)
+	require.Equal(t, "/path/to/missing", result.Target())
+}
+
+func TestNewLinkWithDepsTargetBeneathFile(t *testing.T) {
+	j := Stat{
+		Type:         SymbolicLinkFileType,
+		AbsolutePath: "/path/to/symlink",
+	}
+	mockLstat := func(path string, stat *syscall.Stat_t) error {
+		if path == "/path/to/symlink" {
+			stat.Mode = syscall.S_IFLNK
+			return nil
+		}
+		return syscall.ENOTDIR // "/path/to/file" is a regular file
+	}
+	mockReadlink := func(path string) (string, error) { return "/path/to/file/child", nil }
+	mockIsAbs := func(path string) bool { return true }
+
+	result, err := NewLinkWithDeps(j, mockReadlink, mockLstat, mockIsAbs, filepath.Join, filepath.Dir)
+	require.NoError(t, err)
+	require.True(t, result.Dangling)
+	require.Equal(t, "/path/to/file/child", result.Target())
+}
+
+func TestNewLinkWithDepsPermissionDeniedParent(t *testing.T) {
+	j := Stat{
+		Type:         SymbolicLinkFileType,
+		AbsolutePath: "/path/to/symlink",
+	}
+	mockLstat := func(path string, stat *syscall.Stat_t) error {
+		if path == "/path/to/symlink" {
+			stat.Mode = syscall.S_IFLNK
+			return nil
+		}
+		return syscall.EACCES // "/path/private" cannot be searched
+	}
+	mockReadlink := func(path string) (string, error) { return "/path/private/target", nil }
+	mockIsAbs := func(path string) bool { return true }
+
+	result, err := NewLinkWithDeps(j, mockReadlink, mockLstat, mockIsAbs, filepath.Join, filepath.Dir)
+	require.ErrorIs(t, err, syscall.EACCES)
+	require.Nil(t, result)
+}
+
+func TestNewLinkWithDepsMissingLink(t *testing.T) {
+	j := Stat{
+		Type:         SymbolicLinkFileType,
+		AbsolutePath: "/path/to/symlink",
+	}
+	mockLstat := func(path string, stat *syscall.Stat_t) error {
+		return fmt.Errorf("file does not exist: %s", path)
+	}
+	mockReadlink := func(path string) (string, error) { return "", nil }
+	mockIsAbs := func(path string) bool { return true }
+	mockFilepathJoin := filepath.Join
+	mockFilepathDir := filepath.Dir
+
 	result, err := NewLinkWithDeps(j, mockReadlink, mockLstat, mockIsAbs, mockFilepathJoin, mockFilepathDir)
 	require.Error(t, err)
 	require.Nil(t, result)
@@ -147,7 +209,7 @@ func TestNewLinkWithDepsCyclicSymlink(t *testing.T) {
 	mockFilepathDir := filepath.Dir
 
 	result, err := NewLinkWithDeps(j, mockReadlink, mockLstat, mockIsAbs, mockFilepathJoin, mockFilepathDir)
-	require.Error(t, err)
-	require.Contains(t, err.Error(), "symlink loop detected")
-	require.Nil(t, result)
+	require.NoError(t, err)
+	require.True(t, result.Dangling)
+	require.Equal(t, []string{"/path/to/symlink1", "/path/to/symlink2"}, result.Targets)
 }
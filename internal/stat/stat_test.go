@@ -3,6 +3,7 @@ package stat
 import (
 	"errors"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
 	"os/user"
 	"path/filepath"
 	"syscall"
@@ -16,6 +17,13 @@ func mockUserLookup(uid string) (*user.User, error) {
 	return nil, errors.New("user not found")
 }
 
+func mockGroupLookup(gid string) (*user.Group, error) {
+	if gid == "2000" {
+		return &user.Group{Gid: gid, Name: "testgroup"}, nil
+	}
+	return nil, errors.New("group not found")
+}
+
 func mockPathBasename(path string) string {
 	return filepath.Base(path)
 }
@@ -29,7 +37,7 @@ func TestNewWithDepsPositive(t *testing.T) {
 		Mode:          syscall.S_IFREG | 0644,
 		Size:          12345,
 		Uid:           1000,
-		Gid:           1000,
+		Gid:           2000,
 		Atimespec:     syscall.Timespec{Sec: 1609459200, Nsec: 0}, // 2021-01-01 00:00:00 UTC
 		Mtimespec:     syscall.Timespec{Sec: 1609459300, Nsec: 0}, // 2021-01-01 00:01:40 UTC
 		Ctimespec:     syscall.Timespec{Sec: 1609459400, Nsec: 0}, // 2021-01-01 00:03:20 UTC
@@ -37,18 +45,29 @@ func TestNewWithDepsPositive(t *testing.T) {
 		Blksize:       4096,
 		Blocks:        12,
 		Nlink:         2,
+		Ino:           424242,
 	}
 
-	statResult := NewWithDeps("testfile.txt", stat, mockUserLookup, mockPathBasename, mockPathAbs)
+	statResult := NewWithDeps("testfile.txt", stat, mockUserLookup, mockGroupLookup, mockPathBasename, mockPathAbs)
 
+	assert.Equal(t, SchemaVersion, statResult.SchemaVersion)
 	assert.Equal(t, "testfile.txt", statResult.BaseName)
 	assert.Equal(t, "file", statResult.Type)
 	assert.Equal(t, "testuser", statResult.Owner)
 	assert.Equal(t, "testuser", statResult.UserName)
+	// The group is looked up by gid in the group database, not as a uid.
+	assert.Equal(t, "testgroup", statResult.GroupName)
 	assert.Equal(t, uint32(4096), statResult.BlockSize)
 	assert.Equal(t, uint64(12), statResult.NumBlocks)
-	assert.Equal(t, uint16(2), statResult.HardLinkReferenceCount)
+	assert.Equal(t, uint64(2), statResult.HardLinkReferenceCount)
+	assert.Equal(t, uint64(424242), statResult.Inode)
+	assert.Equal(t, 0.5023, statResult.Sparse)
+	assert.Nil(t, statResult.Rdev)
 	assert.Equal(t, "644", statResult.Permissions.Octal)
+	assert.Equal(t, "rw-", statResult.Permissions.Symbolic.Owner.String())
+	assert.Equal(t, "r--", statResult.Permissions.Symbolic.Group.String())
+	assert.Equal(t, "r--", statResult.Permissions.Symbolic.Other.String())
+	assert.Equal(t, "-rw-r--r--", statResult.ModeString())
 }
 
 func TestNewWithDepsUnknownUser(t *testing.T) {
@@ -66,7 +85,7 @@ func TestNewWithDepsUnknownUser(t *testing.T) {
 		Nlink:         2,
 	}
 
-	statResult := NewWithDeps("testfile.txt", stat, mockUserLookup, mockPathBasename, mockPathAbs)
+	statResult := NewWithDeps("testfile.txt", stat, mockUserLookup, mockGroupLookup, mockPathBasename, mockPathAbs)
 
 	assert.Equal(t, "testfile.txt", statResult.BaseName)
 	assert.Equal(t, "file", statResult.Type)
@@ -87,7 +106,7 @@ func TestNewWithDepsInvalidPath(t *testing.T) {
 		return "", errors.New("invalid path")
 	}
 
-	statResult := NewWithDeps("invalidpath", stat, mockUserLookup, mockPathBasename, mockPathAbsErr)
+	statResult := NewWithDeps("invalidpath", stat, mockUserLookup, mockGroupLookup, mockPathBasename, mockPathAbsErr)
 
 	assert.Equal(t, "directory", statResult.Type)
 	assert.Equal(t, "unknown", statResult.AbsolutePath) // AbsolutePath should fallback to "unknown"
@@ -113,8 +132,51 @@ func TestNewWithDepsSpecialFileTypes(t *testing.T) {
 				Uid:  1000,
 				Gid:  1000,
 			}
-			statResult := NewWithDeps("testfile", stat, mockUserLookup, mockPathBasename, mockPathAbs)
+			statResult := NewWithDeps("testfile", stat, mockUserLookup, mockGroupLookup, mockPathBasename, mockPathAbs)
 			assert.Equal(t, tt.fileType, statResult.Type)
+			isDevice := tt.fileType == CharDeviceFileType || tt.fileType == BlockDeviceFileType
+			assert.Equal(t, isDevice, statResult.Rdev != nil)
+		})
+	}
+}
+
+func TestDeviceID(t *testing.T) {
+	d := deviceID(unix.Mkdev(8, 17))
+	assert.Equal(t, DeviceID{Major: 8, Minor: 17}, d)
+	assert.Equal(t, "8, 17", d.String())
+}
+
+func TestSparseness(t *testing.T) {
+	assert.Equal(t, 0.0, sparseness(0, 0))
+	assert.Equal(t, 0.0, sparseness(5000, 8192))
+	assert.Equal(t, 1.0, sparseness(1<<30, 0))
+	assert.Equal(t, 0.75, sparseness(16384, 4096))
+}
+
+func TestModeString(t *testing.T) {
+	tests := []struct {
+		mode     uint32
+		expected string
+	}{
+		{syscall.S_IFDIR | 0755, "drwxr-xr-x"},
+		{syscall.S_IFLNK | 0777, "lrwxrwxrwx"},
+		{syscall.S_IFREG | syscall.S_ISUID | 0755, "-rwsr-xr-x"},
+		{syscall.S_IFREG | syscall.S_ISUID | 0644, "-rwSr--r--"},
+		{syscall.S_IFDIR | syscall.S_ISGID | 0750, "drwxr-s---"},
+		{syscall.S_IFDIR | syscall.S_ISVTX | 0777, "drwxrwxrwt"},
+		{syscall.S_IFDIR | syscall.S_ISVTX | 0770, "drwxrwx--T"},
+		{syscall.S_IFCHR | 0620, "crw--w----"},
+		{syscall.S_IFIFO | 0600, "prw-------"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			stat := &syscall.Stat_t{
+				Mode: uint16(tt.mode),
+				Uid:  1000,
+				Gid:  1000,
+			}
+			statResult := NewWithDeps("testfile", stat, mockUserLookup, mockGroupLookup, mockPathBasename, mockPathAbs)
+			assert.Equal(t, tt.expected, statResult.ModeString())
 		})
 	}
 }
@@ -0,0 +1,59 @@
+package stat
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Time is a timestamp of an entry. It encodes as an RFC 3339 date-time
+// with nanoseconds, or as Unix seconds when Epoch is set, keeping the
+// fraction of a second in both.
+type Time struct {
+	time.Time
+	// Epoch selects the Unix seconds encoding, as with --json-time epoch.
+	Epoch bool
+}
+
+// MarshalText encodes t as selected by Epoch.
+func (t Time) MarshalText() ([]byte, error) {
+	if !t.Epoch {
+		return t.Time.MarshalText()
+	}
+	return []byte(t.epochSeconds()), nil
+}
+
+// MarshalJSON encodes t as a JSON string, or as a JSON number when Epoch is
+// set.
+func (t Time) MarshalJSON() ([]byte, error) {
+	if !t.Epoch {
+		return t.Time.MarshalJSON()
+	}
+	return []byte(t.epochSeconds()), nil
+}
+
+// epochSeconds formats t as decimal Unix seconds, without trailing zeros in
+// the fraction. Times before the epoch count back from it, so half a second
+// before is -0.5 rather than -1 plus half a second.
+func (t Time) epochSeconds() string {
+	sec, nsec := t.Unix(), int64(t.Nanosecond())
+	sign := ""
+	if sec < 0 && nsec > 0 {
+		sec, nsec = sec+1, 1e9-nsec
+		if sec == 0 {
+			sign = "-"
+		}
+	}
+	s := sign + strconv.FormatInt(sec, 10)
+	if nsec == 0 {
+		return s
+	}
+	frac := strings.TrimRight(strconv.FormatInt(nsec+1e9, 10)[1:], "0")
+	return s + "." + frac
+}
+
+// Times returns the timestamps of s, so that they can all be converted at
+// once.
+func (s *Stat) Times() []*Time {
+	return []*Time{&s.LastAccessedTime, &s.LastModifiedTime, &s.CreateTime, &s.BirthTime}
+}
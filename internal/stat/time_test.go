@@ -0,0 +1,48 @@
+package stat
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeMarshal(t *testing.T) {
+	tests := []struct {
+		name  string
+		time  time.Time
+		epoch bool
+		want  string
+	}{
+		{name: "rfc3339", time: time.Date(2021, time.January, 1, 0, 1, 40, 500000000, time.UTC), want: `"2021-01-01T00:01:40.5Z"`},
+		{name: "rfc3339 zone", time: time.Date(2021, time.January, 1, 9, 0, 0, 0, time.FixedZone("", 9*3600)), want: `"2021-01-01T09:00:00+09:00"`},
+		{name: "epoch", time: time.Unix(1609459300, 0), epoch: true, want: `1609459300`},
+		{name: "epoch fraction", time: time.Unix(1609459300, 500000000), epoch: true, want: `1609459300.5`},
+		{name: "epoch nanoseconds", time: time.Unix(1609459300, 123456789), epoch: true, want: `1609459300.123456789`},
+		{name: "epoch before", time: time.Unix(-2, 250000000), epoch: true, want: `-1.75`},
+		{name: "epoch just before", time: time.Unix(-1, 500000000), epoch: true, want: `-0.5`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := json.Marshal(Time{Time: tt.time, Epoch: tt.epoch})
+			require.NoError(t, err)
+			require.Equal(t, tt.want, string(b))
+		})
+	}
+}
+
+func TestTimeMarshalInStat(t *testing.T) {
+	var s Stat
+	for _, ts := range s.Times() {
+		ts.Time = time.Unix(1609459300, 5)
+		ts.Epoch = true
+	}
+	b, err := json.Marshal(s)
+	require.NoError(t, err)
+	var doc map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(b, &doc))
+	for _, key := range []string{"last_accessed_time", "last_modified_time", "create_time", "birth_time"} {
+		require.Equal(t, "1609459300.000000005", string(doc[key]), key)
+	}
+}
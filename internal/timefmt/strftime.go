@@ -0,0 +1,120 @@
+package timefmt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Strftime formats t with a C strftime(3) format string. Unknown
+// conversions are copied through unchanged, as GNU date does.
+func Strftime(t time.Time, format string) string {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' || i == len(format)-1 {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		b.WriteString(conversion(t, format[i]))
+	}
+	return b.String()
+}
+
+func conversion(t time.Time, verb byte) string {
+	switch verb {
+	case 'a':
+		return t.Format("Mon")
+	case 'A':
+		return t.Format("Monday")
+	case 'b', 'h':
+		return t.Format("Jan")
+	case 'B':
+		return t.Format("January")
+	case 'c':
+		return t.Format("Mon Jan _2 15:04:05 2006")
+	case 'C':
+		return fmt.Sprintf("%02d", t.Year()/100)
+	case 'd':
+		return fmt.Sprintf("%02d", t.Day())
+	case 'D':
+		return t.Format("01/02/06")
+	case 'e':
+		return fmt.Sprintf("%2d", t.Day())
+	case 'F':
+		return t.Format("2006-01-02")
+	case 'G':
+		year, _ := t.ISOWeek()
+		return strconv.Itoa(year)
+	case 'H':
+		return fmt.Sprintf("%02d", t.Hour())
+	case 'I':
+		return fmt.Sprintf("%02d", hour12(t))
+	case 'j':
+		return fmt.Sprintf("%03d", t.YearDay())
+	case 'k':
+		return fmt.Sprintf("%2d", t.Hour())
+	case 'l':
+		return fmt.Sprintf("%2d", hour12(t))
+	case 'm':
+		return fmt.Sprintf("%02d", int(t.Month()))
+	case 'M':
+		return fmt.Sprintf("%02d", t.Minute())
+	case 'n':
+		return "\n"
+	case 'N':
+		return fmt.Sprintf("%09d", t.Nanosecond())
+	case 'p':
+		return t.Format("PM")
+	case 'P':
+		return strings.ToLower(t.Format("PM"))
+	case 'r':
+		return t.Format("03:04:05 PM")
+	case 'R':
+		return t.Format("15:04")
+	case 's':
+		return strconv.FormatInt(t.Unix(), 10)
+	case 'S':
+		return fmt.Sprintf("%02d", t.Second())
+	case 't':
+		return "\t"
+	case 'T':
+		return t.Format("15:04:05")
+	case 'u':
+		wd := int(t.Weekday())
+		if wd == 0 {
+			wd = 7
+		}
+		return strconv.Itoa(wd)
+	case 'V':
+		_, week := t.ISOWeek()
+		return fmt.Sprintf("%02d", week)
+	case 'w':
+		return strconv.Itoa(int(t.Weekday()))
+	case 'x':
+		return t.Format("01/02/06")
+	case 'X':
+		return t.Format("15:04:05")
+	case 'y':
+		return fmt.Sprintf("%02d", t.Year()%100)
+	case 'Y':
+		return strconv.Itoa(t.Year())
+	case 'z':
+		return t.Format("-0700")
+	case 'Z':
+		return t.Format("MST")
+	case '%':
+		return "%"
+	}
+	return "%" + string(verb)
+}
+
+func hour12(t time.Time) int {
+	h := t.Hour() % 12
+	if h == 0 {
+		h = 12
+	}
+	return h
+}
@@ -0,0 +1,95 @@
+package timefmt
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Which timestamp of an entry is shown, from --time.
+const (
+	Modified = "mtime"
+	Accessed = "atime"
+	Changed  = "ctime"
+	Birth    = "birth"
+)
+
+// ParseWhich normalises a --time value, accepting the GNU ls aliases.
+func ParseWhich(s string) (string, error) {
+	switch s {
+	case "", Modified, "modification":
+		return Modified, nil
+	case Accessed, "access", "use":
+		return Accessed, nil
+	case Changed, "status":
+		return Changed, nil
+	case Birth, "creation":
+		return Birth, nil
+	}
+	return "", fmt.Errorf("invalid time %q, expected atime, ctime, birth or mtime", s)
+}
+
+// sixMonths is the GNU ls cut-off between recent and old timestamps: half
+// of an average Gregorian year.
+const sixMonths = 31556952 / 2 * time.Second
+
+// Style formats timestamps for --time-style. Recent timestamps, within the
+// last six months and not in the future, may use a different layout.
+type Style struct {
+	Old    string // strftime format for old timestamps
+	Recent string // strftime format for recent timestamps
+}
+
+// ParseStyle reads a --time-style value: full-iso, long-iso, iso, locale,
+// or +FORMAT where FORMAT is strftime(3) and may hold a second format for
+// recent files after a newline. A posix- prefix is ignored.
+func ParseStyle(s string) (Style, error) {
+	s = strings.TrimPrefix(s, "posix-")
+	switch s {
+	case "full-iso":
+		return Style{Old: "%Y-%m-%d %H:%M:%S.%N %z", Recent: "%Y-%m-%d %H:%M:%S.%N %z"}, nil
+	case "long-iso":
+		return Style{Old: "%Y-%m-%d %H:%M", Recent: "%Y-%m-%d %H:%M"}, nil
+	case "iso":
+		return Style{Old: "%Y-%m-%d ", Recent: "%m-%d %H:%M"}, nil
+	case "", "locale":
+		return Style{Old: "%b %e  %Y", Recent: "%b %e %H:%M"}, nil
+	}
+	if format, ok := strings.CutPrefix(s, "+"); ok {
+		old, recent, found := strings.Cut(format, "\n")
+		if !found {
+			recent = old
+		}
+		return Style{Old: old, Recent: recent}, nil
+	}
+	return Style{}, fmt.Errorf("invalid time style %q, expected full-iso, long-iso, iso, locale or +FORMAT", s)
+}
+
+// Format renders t relative to now.
+func (s Style) Format(t, now time.Time) string {
+	if IsRecent(t, now) {
+		return Strftime(t, s.Recent)
+	}
+	return Strftime(t, s.Old)
+}
+
+// IsRecent reports whether t is within six months before now.
+func IsRecent(t, now time.Time) bool {
+	return !t.After(now) && now.Sub(t) < sixMonths
+}
+
+// ParseLocation returns the zone for --utc and --tz, defaulting to the
+// local one.
+func ParseLocation(utc bool, tz string) (*time.Location, error) {
+	if utc {
+		return time.UTC, nil
+	}
+	if tz == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", tz, err)
+	}
+	return loc, nil
+}
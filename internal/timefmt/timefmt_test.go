@@ -0,0 +1,105 @@
+package timefmt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	now    = time.Date(2024, time.November, 30, 12, 0, 0, 0, time.UTC)
+	recent = time.Date(2024, time.October, 3, 9, 5, 7, 123456789, time.UTC)
+	old    = time.Date(2023, time.March, 14, 15, 9, 26, 0, time.UTC)
+)
+
+func TestStrftime(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"%Y-%m-%d %H:%M:%S", "2024-10-03 09:05:07"},
+		{"%b %e %a %A %B", "Oct  3 Thu Thursday October"},
+		{"%F %T.%N %z %Z", "2024-10-03 09:05:07.123456789 +0000 UTC"},
+		{"%I:%M %p %l %k", "09:05 AM  9  9"},
+		{"%j %u %w %y %C", "277 4 4 24 20"},
+		{"%s", "1727946307"},
+		{"100%% %q", "100% %q"},
+		{"trailing %", "trailing %"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Strftime(recent, tt.format))
+		})
+	}
+}
+
+func TestParseStyle(t *testing.T) {
+	tests := []struct {
+		style  string
+		recent string
+		old    string
+	}{
+		{"full-iso", "2024-10-03 09:05:07.123456789 +0000", "2023-03-14 15:09:26.000000000 +0000"},
+		{"long-iso", "2024-10-03 09:05", "2023-03-14 15:09"},
+		{"iso", "10-03 09:05", "2023-03-14 "},
+		{"locale", "Oct  3 09:05", "Mar 14  2023"},
+		{"posix-long-iso", "2024-10-03 09:05", "2023-03-14 15:09"},
+		{"+%Y", "2024", "2023"},
+		{"+%Y\n%H:%M", "09:05", "2023"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			style, err := ParseStyle(tt.style)
+			require.NoError(t, err)
+			assert.Equal(t, tt.recent, style.Format(recent, now))
+			assert.Equal(t, tt.old, style.Format(old, now))
+		})
+	}
+
+	_, err := ParseStyle("fancy")
+	require.Error(t, err)
+}
+
+func TestIsRecent(t *testing.T) {
+	assert.True(t, IsRecent(recent, now))
+	assert.False(t, IsRecent(old, now))
+	assert.False(t, IsRecent(now.Add(time.Hour), now), "future timestamps are not recent")
+	assert.True(t, IsRecent(now.Add(-sixMonths+time.Second), now))
+	assert.False(t, IsRecent(now.Add(-sixMonths), now))
+}
+
+func TestParseWhich(t *testing.T) {
+	for input, expected := range map[string]string{
+		"":         Modified,
+		"mtime":    Modified,
+		"atime":    Accessed,
+		"access":   Accessed,
+		"use":      Accessed,
+		"ctime":    Changed,
+		"status":   Changed,
+		"birth":    Birth,
+		"creation": Birth,
+	} {
+		which, err := ParseWhich(input)
+		require.NoError(t, err)
+		assert.Equal(t, expected, which, input)
+	}
+
+	_, err := ParseWhich("yesterday")
+	require.Error(t, err)
+}
+
+func TestParseLocation(t *testing.T) {
+	loc, err := ParseLocation(true, "Asia/Tokyo")
+	require.NoError(t, err)
+	assert.Equal(t, time.UTC, loc)
+
+	loc, err = ParseLocation(false, "")
+	require.NoError(t, err)
+	assert.Equal(t, time.Local, loc)
+
+	_, err = ParseLocation(false, "Nowhere/Special")
+	require.Error(t, err)
+}
@@ -0,0 +1,163 @@
+package walk
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"syscall"
+
+	"github.com/sochoa/go-ls/internal/mount"
+	"github.com/sochoa/go-ls/internal/stat"
+)
+
+// Enricher fills in optional fields of a freshly built stat. Enrichers run
+// before symbolic links are resolved so the fields carry over into the
+// stat.StatLink.
+type Enricher func(s *stat.Stat)
+
+// Walker builds entries for paths and the trees beneath them.
+type Walker struct {
+	Enrichers []Enricher
+	// Filter decides whether an entry is reported and whether a directory
+	// is descended into. Nil reports everything.
+	Filter func(m stat.CommonStat) (match, descend bool)
+	// Ignore hides paths beneath the root before they are read, so ignored
+	// directories are never descended into. Nil hides nothing.
+	Ignore func(path string, isDir bool) bool
+	// OneFileSystem keeps walks from descending into directories on
+	// another file system than their root: those on another device, or
+	// mount points in Mounts, which also catches bind mounts.
+	OneFileSystem bool
+	Mounts        mount.Table
+}
+
+// Entry builds the stat for path without following it, so symbolic links
+// come back as a stat.StatLink with their targets resolved.
+func Entry(path string) (stat.CommonStat, error) {
+	return Walker{}.Entry(path)
+}
+
+// Follow builds the stat for whatever path points at, the way the top-level
+// arguments of ls are treated. When path is a link whose target is missing
+// the link itself is returned so that it can still be listed as dangling.
+func Follow(path string) (stat.CommonStat, error) {
+	return Walker{}.Follow(path)
+}
+
+// Walk calls fn for root and every path beneath it, in lexical order. Links
+// are reported but never descended into. An error from fn stops the walk;
+// errors building an entry are handed to fn so it can decide. Entries the
+// walker's Filter rejects are skipped, and directories it prunes, Ignore
+// hides or OneFileSystem keeps out of are not read at all.
+func Walk(root string, fn func(path string, m stat.CommonStat, err error) error) error {
+	return Walker{}.Walk(root, fn)
+}
+
+func (w Walker) Entry(path string) (stat.CommonStat, error) {
+	return w.EntryWithDeps(path, syscall.Lstat)
+}
+
+func (w Walker) Follow(path string) (stat.CommonStat, error) {
+	return w.FollowWithDeps(path, syscall.Stat, syscall.Lstat)
+}
+
+func (w Walker) Walk(root string, fn func(path string, m stat.CommonStat, err error) error) error {
+	var rootEntry stat.CommonStat
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fn(path, nil, err)
+		}
+		if path != root && w.Ignored(path, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		m, err := w.Entry(path)
+		if err != nil {
+			return fn(path, nil, err)
+		}
+		if path == root {
+			rootEntry = m
+		}
+		match, descend := w.Match(m)
+		if match {
+			if err := fn(path, m, nil); err != nil {
+				return err
+			}
+		}
+		if d.IsDir() && (!descend || path != root && w.Crosses(rootEntry, m)) {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
+// Crosses reports whether m is a directory that OneFileSystem keeps a walk
+// from root out of.
+func (w Walker) Crosses(root, m stat.CommonStat) bool {
+	if !w.OneFileSystem || root == nil || m.GetType() != stat.DirectoryFileType {
+		return false
+	}
+	return m.GetStat().Device != root.GetStat().Device || w.Mounts.IsMountPoint(m.GetAbsolutePath())
+}
+
+// Ignored applies the walker's Ignore to path.
+func (w Walker) Ignored(path string, isDir bool) bool {
+	return w.Ignore != nil && w.Ignore(path, isDir)
+}
+
+// Match applies the walker's Filter to m.
+func (w Walker) Match(m stat.CommonStat) (match, descend bool) {
+	if w.Filter == nil {
+		return true, true
+	}
+	return w.Filter(m)
+}
+
+func (w Walker) EntryWithDeps(path string, lstat func(string, *syscall.Stat_t) error) (stat.CommonStat, error) {
+	var s syscall.Stat_t
+	if err := lstat(path, &s); err != nil {
+		return nil, fmt.Errorf("error statting %s: %w", path, err)
+	}
+	m := w.newStat(path, &s)
+	if m.Type != stat.SymbolicLinkFileType {
+		return m, nil
+	}
+	l, err := stat.NewLink(m)
+	if err != nil {
+		return nil, fmt.Errorf("error reading symlink %s: %w", path, err)
+	}
+	return *l, nil
+}
+
+func (w Walker) FollowWithDeps(
+	path string,
+	statPath func(string, *syscall.Stat_t) error,
+	lstat func(string, *syscall.Stat_t) error,
+) (stat.CommonStat, error) {
+	var s syscall.Stat_t
+	err := statPath(path, &s)
+	if err == nil {
+		return w.newStat(path, &s), nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, syscall.ENOTDIR) && !errors.Is(err, syscall.ELOOP) {
+		return nil, fmt.Errorf("error statting %s: %w", path, err)
+	}
+	return w.EntryWithDeps(path, lstat)
+}
+
+func (w Walker) newStat(path string, s *syscall.Stat_t) stat.Stat {
+	m := stat.New(path, s)
+	for _, enrich := range w.Enrichers {
+		enrich(&m)
+	}
+	return m
+}
+
+// IsDangling reports whether m is a symbolic link whose target is missing.
+func IsDangling(m stat.CommonStat) bool {
+	l, ok := m.(stat.StatLink)
+	return ok && l.Dangling
+}
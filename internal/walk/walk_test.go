@@ -0,0 +1,172 @@
+package walk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sochoa/go-ls/internal/mount"
+	"github.com/sochoa/go-ls/internal/stat"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFollowDanglingLink(t *testing.T) {
+	dir := t.TempDir()
+	link := filepath.Join(dir, "broken")
+	require.NoError(t, os.Symlink("missing", link))
+
+	m, err := Follow(link)
+	require.NoError(t, err)
+	require.True(t, IsDangling(m))
+
+	l := m.(stat.StatLink)
+	require.Equal(t, filepath.Join(dir, "missing"), l.Target())
+}
+
+func TestFollowResolvesLink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+	require.NoError(t, os.WriteFile(target, []byte("x"), 0o644))
+	link := filepath.Join(dir, "link")
+	require.NoError(t, os.Symlink("target", link))
+
+	m, err := Follow(link)
+	require.NoError(t, err)
+	require.Equal(t, stat.RegularFileType, m.GetType())
+	require.False(t, IsDangling(m))
+
+	m, err = Entry(link)
+	require.NoError(t, err)
+	require.Equal(t, stat.SymbolicLinkFileType, m.GetType())
+	require.False(t, IsDangling(m))
+}
+
+func TestLinkLoopIsDangling(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	require.NoError(t, os.Symlink("b", a))
+	require.NoError(t, os.Symlink("a", b))
+
+	m, err := Follow(a)
+	require.NoError(t, err)
+	require.True(t, IsDangling(m))
+	require.Equal(t, []string{a, b}, m.(stat.StatLink).Targets)
+
+	var listed []string
+	err = Walk(dir, func(path string, m stat.CommonStat, err error) error {
+		require.NoError(t, err)
+		if IsDangling(m) {
+			listed = append(listed, path)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{a, b}, listed)
+}
+
+func TestWalkFindsNestedDanglingLinks(t *testing.T) {
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "a", "b")
+	require.NoError(t, os.MkdirAll(nested, 0o755))
+	require.NoError(t, os.Symlink("gone", filepath.Join(nested, "broken")))
+	require.NoError(t, os.Symlink("b", filepath.Join(dir, "a", "ok")))
+
+	var dangling []string
+	err := Walk(dir, func(path string, m stat.CommonStat, err error) error {
+		require.NoError(t, err)
+		if IsDangling(m) {
+			dangling = append(dangling, path)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(nested, "broken")}, dangling)
+}
+
+func TestWalkFilterPrunes(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "skip", "deep"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "keep"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "keep", "a.go"), nil, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "keep", "b.txt"), nil, 0o644))
+
+	var visited []string
+	w := Walker{Filter: func(m stat.CommonStat) (bool, bool) {
+		name := m.GetStat().BaseName
+		visited = append(visited, name)
+		if name == "skip" {
+			return false, false
+		}
+		return filepath.Ext(name) == ".go", true
+	}}
+	var matched []string
+	err := w.Walk(dir, func(path string, m stat.CommonStat, err error) error {
+		require.NoError(t, err)
+		matched = append(matched, m.GetStat().BaseName)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a.go"}, matched)
+	require.NotContains(t, visited, "deep")
+}
+
+func TestWalkIgnore(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "node_modules", "react"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), nil, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "debug.log"), nil, 0o644))
+
+	var asked []string
+	w := Walker{Ignore: func(path string, isDir bool) bool {
+		name := filepath.Base(path)
+		asked = append(asked, name)
+		return name == "node_modules" && isDir || filepath.Ext(name) == ".log"
+	}}
+	var walked []string
+	err := w.Walk(dir, func(path string, m stat.CommonStat, err error) error {
+		require.NoError(t, err)
+		walked = append(walked, m.GetStat().BaseName)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Base(dir), "main.go"}, walked)
+	require.NotContains(t, asked, "react")
+	require.NotContains(t, asked, filepath.Base(dir))
+}
+
+func TestWalkOneFileSystem(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "mnt", "inside"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "local"), 0o755))
+
+	// The mount table marks mnt as a mount point, as a bind mount on the
+	// same device would be.
+	mounts := mount.Table{{MountPoint: filepath.Join(dir, "mnt")}}
+	for _, oneFileSystem := range []bool{false, true} {
+		var visited []string
+		w := Walker{OneFileSystem: oneFileSystem, Mounts: mounts}
+		err := w.Walk(dir, func(path string, m stat.CommonStat, err error) error {
+			require.NoError(t, err)
+			rel, err := filepath.Rel(dir, path)
+			require.NoError(t, err)
+			visited = append(visited, rel)
+			return nil
+		})
+		require.NoError(t, err)
+		want := []string{".", "local", "mnt", "mnt/inside"}
+		if oneFileSystem {
+			// The mount point is reported but not descended into.
+			want = want[:3]
+		}
+		require.Equal(t, want, visited)
+	}
+
+	// A root that is a mount point itself is walked.
+	var visited []string
+	w := Walker{OneFileSystem: true, Mounts: mounts}
+	require.NoError(t, w.Walk(filepath.Join(dir, "mnt"), func(path string, m stat.CommonStat, err error) error {
+		visited = append(visited, filepath.Base(path))
+		return nil
+	}))
+	require.Equal(t, []string{"mnt", "inside"}, visited)
+}